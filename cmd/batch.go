@@ -2,13 +2,16 @@ package main
 
 import (
 	"io"
+	"time"
 
 	"github.com/schollz/progressbar/v3"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/Azure/kdebug/pkg/base"
 	"github.com/Azure/kdebug/pkg/batch"
+	chks "github.com/Azure/kdebug/pkg/checkers"
 	"github.com/Azure/kdebug/pkg/formatters"
+	"github.com/Azure/kdebug/pkg/kdebug"
 )
 
 func getBatchDiscoverer(opts *Options, chkCtx *base.CheckContext) batch.BatchDiscoverer {
@@ -57,7 +60,7 @@ func (r *batchReporter) OnResult(result *batch.BatchResult) {
 	r.bar.Add(1)
 }
 
-func runBatch(opts *Options, chkCtx *base.CheckContext, formatter formatters.Formatter) {
+func runBatch(opts *Options, runner *kdebug.Runner, chkCtx *base.CheckContext, formatter formatters.Formatter, config []byte) {
 	discoverer := getBatchDiscoverer(opts, chkCtx)
 	machines, err := discoverer.Discover()
 	if err != nil {
@@ -71,18 +74,33 @@ func runBatch(opts *Options, chkCtx *base.CheckContext, formatter formatters.For
 	if opts.Batch.Concurrency > 0 {
 		concurrency = opts.Batch.Concurrency
 	}
+	checks, err := chks.DeclarativeData(runner.Checkers())
+	if err != nil {
+		log.Fatal(err)
+	}
 	batchOpts := &batch.BatchOptions{
-		Machines:    machines,
-		Checkers:    opts.Checkers,
+		Machines: machines,
+		// Forward checker parameters to remote runs
+		Checkers:    runner.CheckerSpecs(),
+		Config:      config,
+		Checks:      checks,
+		Plugins:     chks.PluginFiles(runner.Checkers()),
+		Env:         opts.envOptions(),
 		Concurrency: concurrency,
 		Reporter:    newBatchReporter(chkCtx.Output, int64(len(machines))),
 	}
-	batchResults, err := executor.Execute(batchOpts)
+	run := &batch.BatchRun{
+		StartTime: time.Now(),
+		Checkers:  batchOpts.Checkers,
+		Executor:  executor.Name(),
+	}
+	run.Results, err = executor.Execute(batchOpts)
 	if err != nil {
 		log.Fatalf("Fail to run batch: %s", err)
 	}
+	run.EndTime = time.Now()
 
-	err = formatter.WriteBatchResults(chkCtx.Output, batchResults)
+	err = formatter.WriteBatchResults(chkCtx.Output, run)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Azure/kdebug/pkg/batch"
+	"github.com/Azure/kdebug/pkg/formatters"
+	"github.com/Azure/kdebug/pkg/kdebug"
+)
+
+// listContexts returns contexts given with --contexts, or all contexts of
+// the kubeconfig with --all-contexts.
+func listContexts(opts *Options) ([]string, error) {
+	if !opts.AllContexts {
+		contexts := []string{}
+		for _, spec := range opts.Contexts {
+			for _, name := range strings.Split(spec, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					contexts = append(contexts, name)
+				}
+			}
+		}
+		return contexts, nil
+	}
+
+	raw, err := newKubeConfigFlags(opts).ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("Fail to load kubeconfig: %s", err)
+	}
+	contexts := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		contexts = append(contexts, name)
+	}
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("No contexts found in kubeconfig")
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
+// runClusters runs checkers against clusters of several contexts at once.
+// Only cluster checkers run unless checks or groups are given.
+func runClusters(ctx context.Context, opts *Options, formatter formatters.Formatter, output io.Writer) ([]*batch.BatchResult, error) {
+	if opts.KubeContext != "" || opts.IsBatchMode() {
+		return nil, fmt.Errorf("--contexts and --all-contexts cannot be combined with --context or batch mode")
+	}
+	contexts, err := listContexts(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Checkers) == 0 && len(opts.Groups) == 0 && opts.Pod == "" {
+		opts.Groups = []string{"cluster"}
+	}
+	// Config of checkers is shared by all clusters
+	cfg, _, err := loadConfig(opts, loadKubeClients(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	run := &batch.BatchRun{
+		StartTime: time.Now(),
+		Executor:  "cluster",
+	}
+	// Runners are built up front so that invalid options fail before any cluster is checked
+	results := make([]*batch.BatchResult, len(contexts))
+	runners := make([]*kdebug.Runner, len(contexts))
+	for i, name := range contexts {
+		results[i] = &batch.BatchResult{Cluster: name}
+		clusterOpts := *opts
+		clusterOpts.KubeContext = name
+		kube, err := buildKubeClients(&clusterOpts)
+		if err != nil {
+			results[i].Error = fmt.Errorf("Fail to build Kubernetes client: %s", err)
+			continue
+		}
+		// Facts of nodes are read from the cluster checked by the runner
+		environment := newEnvironment(&clusterOpts, kube)
+		if runners[i], err = buildRunner(&clusterOpts, environment, kube, cfg, formatter, output); err != nil {
+			return nil, err
+		}
+		run.Checkers = runners[i].CheckerSpecs()
+	}
+
+	concurrency := opts.ContextsConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range contexts {
+		if runners[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(result *batch.BatchResult, runner *kdebug.Runner) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result.CheckResults, result.Error = runner.Check(ctx)
+			log.WithFields(log.Fields{"cluster": result.Cluster}).Info("Checked cluster")
+		}(results[i], runners[i])
+	}
+	wg.Wait()
+	run.EndTime = time.Now()
+	run.Results = results
+
+	return results, formatter.WriteBatchResults(output, run)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	configMapPrefix = "configmap:"
+	configMapKey    = "kdebug.yaml"
+)
+
+// readConfig reads the config file from a path or from a ConfigMap
+// specified as configmap:<namespace>/<name>.
+func readConfig(opts *Options, kubeClient kubernetes.Interface) ([]byte, error) {
+	if opts.ConfigData != "" {
+		// Forwarded by batch executors
+		return base64.StdEncoding.DecodeString(opts.ConfigData)
+	}
+	if opts.Config == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(opts.Config, configMapPrefix) {
+		return os.ReadFile(opts.Config)
+	}
+
+	ref := strings.SplitN(strings.TrimPrefix(opts.Config, configMapPrefix), "/", 2)
+	if len(ref) != 2 {
+		return nil, fmt.Errorf("Invalid config map %q. Expect %s<namespace>/<name>", opts.Config, configMapPrefix)
+	}
+	if kubeClient == nil {
+		return nil, fmt.Errorf("Kubernetes client is required to read config map %s", opts.Config)
+	}
+	cm, err := kubeClient.CoreV1().ConfigMaps(ref[0]).Get(context.Background(), ref[1], metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Fail to get config map %s: %s", opts.Config, err)
+	}
+	data, ok := cm.Data[configMapKey]
+	if !ok {
+		return nil, fmt.Errorf("Config map %s has no key %s", opts.Config, configMapKey)
+	}
+	return []byte(data), nil
+}
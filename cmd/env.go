@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Azure/kdebug/pkg/env"
+)
+
+// printEnvironment prints detected facts and flags of the environment, in
+// JSON with -f json or YAML otherwise.
+func printEnvironment(out io.Writer, environment env.Environment, format string) error {
+	facts := environment.GetFacts()
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "    ")
+		return enc.Encode(facts)
+	}
+	data, err := yaml.Marshal(facts)
+	if err != nil {
+		return fmt.Errorf("Fail to marshal environment: %s", err)
+	}
+	if _, err := out.Write(data); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "flags: %s\n", strings.Join(environment.GetFlags(), ","))
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	chks "github.com/Azure/kdebug/pkg/checkers"
+)
+
+func printCheckers(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTAGS\tREQUIRES\tPARAMS\tDESCRIPTION")
+	for _, name := range chks.ListAllCheckerNames() {
+		meta, err := chks.GetCheckerMetadata(name)
+		if err != nil {
+			continue
+		}
+		requires := append([]string{}, meta.RequiredFlags...)
+		if meta.NeedsRoot {
+			requires = append(requires, "root")
+		}
+		if meta.NeedsKubeClient {
+			requires = append(requires, "kubeconfig")
+		}
+		if meta.NeedsPod {
+			requires = append(requires, "pod")
+		}
+		params := make([]string, 0, len(meta.Parameters))
+		for param := range meta.Parameters {
+			params = append(params, param)
+		}
+		sort.Strings(params)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name,
+			orNone(strings.Join(meta.Tags, ",")),
+			orNone(strings.Join(requires, ",")),
+			orNone(strings.Join(params, ",")),
+			meta.Description)
+	}
+	w.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
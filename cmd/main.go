@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
-	"path/filepath"
-	"runtime/debug"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/fatih/color"
 	flags "github.com/jessevdk/go-flags"
@@ -13,30 +18,67 @@ import (
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
+	"k8s.io/client-go/rest"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/batch"
 	chks "github.com/Azure/kdebug/pkg/checkers"
+	"github.com/Azure/kdebug/pkg/checkers/declarative"
+	"github.com/Azure/kdebug/pkg/checkers/plugin"
+	"github.com/Azure/kdebug/pkg/config"
 	"github.com/Azure/kdebug/pkg/env"
 	"github.com/Azure/kdebug/pkg/formatters"
+	"github.com/Azure/kdebug/pkg/kdebug"
+	"github.com/Azure/kdebug/pkg/kubecache"
 	tools "github.com/Azure/kdebug/pkg/tools"
 )
 
+const (
+	exitCodeFailure = 1
+	exitCodeWarning = 2
+	exitCodeErrored = 3
+)
+
 type Options struct {
-	ListCheckers   bool     `short:"l" long:"list" description:"List all checks and tools"`
-	Checkers       []string `short:"c" long:"check" description:"Check name. Can specify multiple times."`
-	Tool           string   `short:"t" long:"tool" description:"Use tool"`
-	Format         string   `short:"f" long:"format" description:"Output format"`
-	KubeMasterUrl  string   `long:"kube-master-url" description:"Kubernetes API server URL"`
-	KubeConfigPath string   `long:"kube-config-path" description:"Path to kubeconfig file"`
-	Verbose        string   `short:"v" long:"verbose" description:"Log level"`
-	NoColor        bool     `long:"no-color" description:"Disable colorized output"`
-	Pause          bool     `long:"pause" description:"Pause until interrupted"`
-	Help           bool     `short:"h" long:"help" description:"Show help message"`
-	NoSetExitCode  bool     `long:"no-set-exit-code" hidden:"-"`
-	Output         string   `short:"o" long:"output" description:"Output file"`
+	ListCheckers        bool          `short:"l" long:"list" description:"List all checks and tools"`
+	ShowEnv             bool          `long:"env" description:"Show the detected environment, e.g. OS, kernel, kubelet and cloud"`
+	EnvFlags            []string      `long:"env-flag" description:"Environment flag to force without detecting it, e.g. azure, aks, k8s or ubuntu. Can specify multiple times."`
+	NoDetect            bool          `long:"no-detect" description:"Skip detecting the distro, cloud and Kubernetes. Only the OS, root and flags given with --env-flag are set."`
+	Checkers            []string      `short:"c" long:"check" description:"Check name, optionally with parameters, e.g. dns:server=10.0.0.10,query=example.com. Can specify multiple times."`
+	Groups              []string      `short:"g" long:"group" description:"Run checks tagged with the group, e.g. network. Can specify multiple times."`
+	Skip                []string      `long:"skip" description:"Check name to exclude. Can specify multiple times."`
+	Tool                string        `short:"t" long:"tool" description:"Use tool"`
+	Format              string        `short:"f" long:"format" description:"Output format: text, oneline, json, junit, html or markdown"`
+	KubeMasterUrl       string        `long:"kube-master-url" description:"Kubernetes API server URL"`
+	KubeConfigPath      string        `long:"kube-config-path" description:"Path to kubeconfig file"`
+	KubeContext         string        `long:"context" description:"Kubeconfig context to use"`
+	Contexts            []string      `long:"contexts" description:"Kubeconfig contexts of clusters to check at once, e.g. a,b,c. Can specify multiple times."`
+	AllContexts         bool          `long:"all-contexts" description:"Check clusters of all kubeconfig contexts at once"`
+	ContextsConcurrency int           `long:"contexts-concurrency" default:"4" description:"Max number of clusters checked at the same time with --contexts or --all-contexts"`
+	As                  string        `long:"as" description:"User to impersonate for Kubernetes API requests"`
+	AsGroups            []string      `long:"as-group" description:"Group to impersonate for Kubernetes API requests. Can specify multiple times."`
+	Verbose             string        `short:"v" long:"verbose" description:"Log level"`
+	NoColor             bool          `long:"no-color" description:"Disable colorized output"`
+	Pause               bool          `long:"pause" description:"Pause until interrupted"`
+	Help                bool          `short:"h" long:"help" description:"Show help message"`
+	NoSetExitCode       bool          `long:"no-set-exit-code" hidden:"-"`
+	Output              string        `short:"o" long:"output" description:"Output file"`
+	Timeout             time.Duration `long:"timeout" description:"Timeout of the whole run, e.g. 10m. No limit by default."`
+	CheckerTimeout      []string      `long:"checker-timeout" description:"Timeout of a single checker, e.g. 30s, or of a specific checker, e.g. dns=30s. Can specify multiple times."`
+	Parallelism         int           `long:"parallelism" default:"4" description:"Max number of checkers running at the same time"`
+	Config              string        `long:"config" description:"Path to config file with checker settings, or configmap:<namespace>/<name> to read key kdebug.yaml of a config map"`
+	ConfigData          string        `long:"config-data" hidden:"-"`
+	ChecksFiles         []string      `long:"checks-file" description:"Path to a YAML file of declarative checks, or a directory of them, in addition to ~/.kdebug/checks and /etc/kdebug/checks.d. Can specify multiple times."`
+	ChecksData          string        `long:"checks-data" hidden:"-"`
+	PluginDirs          []string      `long:"plugin-dir" description:"Directory to discover plugin checkers in, in addition to ~/.kdebug/plugins and /etc/kdebug/plugins.d. Can specify multiple times."`
+
+	Namespaces        []string `short:"n" long:"namespace" description:"Namespace Kubernetes checkers look into. Can specify multiple times. All namespaces by default, falling back to the namespace of the kubeconfig context if forbidden."`
+	AllNamespaces     bool     `short:"A" long:"all-namespaces" description:"Kubernetes checkers look into all namespaces without falling back"`
+	ExcludeNamespaces []string `long:"exclude-namespace" description:"Namespace Kubernetes checkers ignore, e.g. kube-system. Can specify multiple times."`
+	Pod               string   `long:"pod" description:"Diagnose a pod, e.g. default/web-0. The namespace defaults to the one given with --namespace, or the one of the kubeconfig context. Only pod checkers run unless checks or groups are given."`
+	Selector          string   `long:"selector" description:"Label selector of pods, config maps, secrets and services Kubernetes checkers look into, e.g. app=web"`
 
 	Batch struct {
 		KubeMachines              bool     `long:"kube-machines" description:"Discover machines from Kubernetes API server"`
@@ -58,82 +100,259 @@ func (o *Options) IsBatchMode() bool {
 	return o.Batch.KubeMachines || o.Batch.KubeMachinesUnready || len(o.Batch.Machines) > 0 || len(o.Batch.MachinesFile) > 0
 }
 
+func (o *Options) IsMultiClusterMode() bool {
+	return len(o.Contexts) > 0 || o.AllContexts
+}
+
+func (o *Options) envOptions() env.Options {
+	return env.Options{Flags: o.EnvFlags, NoDetect: o.NoDetect}
+}
+
 func (o *Options) IsToolMode() bool {
 	return len(o.Tool) > 0
 }
 
 func getDefaultPodExecutorImage() string {
 	tag := "main"
-	if info, ok := debug.ReadBuildInfo(); ok {
-		for _, setting := range info.Settings {
-			if setting.Key == "vcs.revision" {
-				tag = setting.Value
-				break
-			}
-		}
+	if revision := base.BuildRevision(); revision != "" {
+		tag = revision
 	}
 	return "ghcr.io/azure/kdebug:" + tag
 }
 
-func processOptions(o *Options) {
-	// Run all checkers if not specified
-	if len(o.Checkers) == 0 {
-		o.Checkers = chks.ListAllCheckerNames()
+func processOptions(o *Options) error {
+	envOpts := o.envOptions()
+	if err := envOpts.Validate(); err != nil {
+		return err
 	}
+	chks.LoadPlugins(append(o.PluginDirs, plugin.DefaultDirs()...))
+	// Forwarded by batch executors
+	checksData, err := base64.StdEncoding.DecodeString(o.ChecksData)
+	if err != nil {
+		return fmt.Errorf("Invalid checks data: %s", err)
+	}
+	err = chks.LoadDeclarativeChecks(checksData, append(o.ChecksFiles, declarative.DefaultDirs()...))
+	if err != nil {
+		return err
+	}
+
 	if o.Batch.PodExecutorImage == "" {
 		o.Batch.PodExecutorImage = getDefaultPodExecutorImage()
 	}
+	return nil
 }
 
-func buildKubeClient(masterUrl, kubeConfigPath string) (*kubernetes.Clientset, *genericclioptions.ConfigFlags, error) {
-	// Try env
-	if kubeConfigPath == "" {
-		if path := os.Getenv("KUBECONFIG"); path != "" {
-			kubeConfigPath = path
+// getExitCode returns 1 if any check failed, 3 if any checker could not run
+// and 2 if there are only warnings.
+func getExitCode(s base.Summary) int {
+	if s.Fail > 0 {
+		return exitCodeFailure
+	}
+	if s.Errored > 0 {
+		return exitCodeErrored
+	}
+	if s.Warn > 0 {
+		return exitCodeWarning
+	}
+	return 0
+}
+
+// getBatchExitCode is like getExitCode, with machines that fail to run as errored.
+func getBatchExitCode(s batch.ReportSummary) int {
+	code := getExitCode(s.Checks())
+	if s.MachinesErrored > 0 && (code == 0 || code == exitCodeWarning) {
+		return exitCodeErrored
+	}
+	return code
+}
+
+func parseCheckerTimeouts(specs []string) (time.Duration, map[string]time.Duration, error) {
+	timeout := chks.DefaultTimeout
+	timeouts := map[string]time.Duration{}
+	for _, spec := range specs {
+		name, value := "", spec
+		if i := strings.Index(spec, "="); i >= 0 {
+			name, value = spec[:i], spec[i+1:]
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, nil, fmt.Errorf("Invalid checker timeout %q: %s", spec, err)
+		}
+		if name == "" {
+			timeout = d
+		} else {
+			timeouts[name] = d
 		}
 	}
+	return timeout, timeouts, nil
+}
 
-	// Try default path
-	if kubeConfigPath == "" {
-		if home := homedir.HomeDir(); home != "" {
-			kubeConfigPath = filepath.Join(home, ".kube", "config")
+// parsePodRef parses <namespace>/<name>, or <name> in the namespace given with
+// --namespace, falling back to the namespace of the kubeconfig context.
+func parsePodRef(spec string, namespaces []string, contextNamespace string) (base.PodRef, error) {
+	if spec == "" {
+		return base.PodRef{}, nil
+	}
+	parts := strings.Split(spec, "/")
+	if len(parts) == 1 {
+		namespace := contextNamespace
+		switch len(namespaces) {
+		case 0:
+		case 1:
+			namespace = namespaces[0]
+		default:
+			return base.PodRef{}, fmt.Errorf("Namespace of pod %q is ambiguous with multiple --namespace. Expect <namespace>/<name>", spec)
+		}
+		if namespace == "" {
+			namespace = "default"
 		}
+		parts = []string{namespace, parts[0]}
 	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return base.PodRef{}, fmt.Errorf("Invalid pod %q. Expect <namespace>/<name>", spec)
+	}
+	return base.PodRef{Namespace: parts[0], Name: parts[1]}, nil
+}
 
-	config, err := clientcmd.BuildConfigFromFlags(masterUrl, kubeConfigPath)
+type kubeClients struct {
+	client      kubernetes.Interface
+	dynamic     dynamic.Interface
+	config      *rest.Config
+	configFlags *genericclioptions.ConfigFlags
+	// namespace of the current context
+	namespace string
+}
+
+// newKubeConfigFlags returns flags to load kubeconfig with. KUBECONFIG and
+// $HOME/.kube/config are used if no path is given, and the in-cluster config
+// if neither exists and kdebug runs in a pod.
+func newKubeConfigFlags(opts *Options) *genericclioptions.ConfigFlags {
+	kubeFlags := genericclioptions.NewConfigFlags(false)
+	kubeFlags.KubeConfig = &opts.KubeConfigPath
+	kubeFlags.APIServer = &opts.KubeMasterUrl
+	kubeFlags.Context = &opts.KubeContext
+	kubeFlags.Impersonate = &opts.As
+	kubeFlags.ImpersonateGroup = &opts.AsGroups
+	return kubeFlags
+}
+
+func buildKubeClients(opts *Options) (*kubeClients, error) {
+	kubeFlags := newKubeConfigFlags(opts)
+	config, err := kubeFlags.ToRESTConfig()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	clientSet, err := kubernetes.NewForConfig(config)
+	raw, err := kubeFlags.ToRawKubeConfigLoader().RawConfig()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	kubeConfigFlag := genericclioptions.NewConfigFlags(false)
-	kubeConfigFlag.APIServer = &masterUrl
-	kubeConfigFlag.KubeConfig = &kubeConfigPath
-
-	return clientSet, kubeConfigFlag, nil
-}
+	if len(raw.Clusters) == 0 && opts.KubeMasterUrl == "" {
+		// Loading falls back to localhost:8080 like kubectl. Use the in-cluster config or fail instead.
+		if config, err = rest.InClusterConfig(); err != nil {
+			return nil, fmt.Errorf("No kubeconfig found and not running in a pod: %s", err)
+		}
+	}
+	// Overrides are not applied to the in-cluster config
+	if opts.As != "" {
+		config.Impersonate.UserName = opts.As
+		config.Impersonate.Groups = opts.AsGroups
+	}
+	log.WithFields(log.Fields{
+		"server": config.Host,
+		"as":     config.Impersonate.UserName,
+	}).Debug("Kubernetes config")
 
-func buildCheckContext(opts *Options) (*base.CheckContext, error) {
-	ctx := &base.CheckContext{
-		Environment: env.GetEnvironment(),
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	namespace, _, err := kubeFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		namespace = ""
 	}
 
-	log.WithFields(log.Fields{
-		"env": ctx.Environment,
-	}).Debug("Environment")
+	return &kubeClients{
+		client:      clientSet,
+		dynamic:     dynamicClient,
+		config:      config,
+		configFlags: kubeFlags,
+		namespace:   namespace,
+	}, nil
+}
 
-	kubeClient, _, err := buildKubeClient(opts.KubeMasterUrl, opts.KubeConfigPath)
-	if err == nil {
-		ctx.KubeClient = kubeClient
-	} else {
+// loadKubeClients builds clients of the cluster given by options, or empty
+// clients so that Kubernetes checkers are skipped.
+func loadKubeClients(opts *Options) *kubeClients {
+	kube, err := buildKubeClients(opts)
+	if err != nil {
 		log.WithFields(log.Fields{
 			"error": err,
 		}).Warn("Kubernetes related checkers will not work")
+		return &kubeClients{}
 	}
+	return kube
+}
 
-	return ctx, nil
+// loadConfig reads and parses the config of checkers. Both are nil if there is no config.
+func loadConfig(opts *Options, kube *kubeClients) (*config.Config, []byte, error) {
+	configData, err := readConfig(opts, kube.client)
+	if err != nil || configData == nil {
+		return nil, nil, err
+	}
+	cfg, err := config.Parse(configData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Fail to parse config: %s", err)
+	}
+	return cfg, configData, nil
+}
+
+func buildRunner(opts *Options, environment env.Environment, kube *kubeClients, cfg *config.Config, formatter formatters.Formatter, output io.Writer) (*kdebug.Runner, error) {
+	timeout, timeouts, err := parseCheckerTimeouts(opts.CheckerTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err := parsePodRef(opts.Pod, opts.Namespaces, kube.namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	runner, err := kdebug.NewRunner(kdebug.Options{
+		Checkers:      opts.Checkers,
+		Groups:        opts.Groups,
+		Skip:          opts.Skip,
+		Pod:           pod,
+		Config:        cfg,
+		Environment:   environment,
+		KubeClient:    kube.client,
+		DynamicClient: kube.dynamic,
+		KubeConfig:    kube.config,
+		KubeScope: kubecache.Scope{
+			Namespaces:        opts.Namespaces,
+			AllNamespaces:     opts.AllNamespaces,
+			ExcludeNamespaces: opts.ExcludeNamespaces,
+			LabelSelector:     opts.Selector,
+			FallbackNamespace: kube.namespace,
+		},
+		Timeout:         timeout,
+		CheckerTimeouts: timeouts,
+		Parallelism:     opts.Parallelism,
+		Formatter:       formatter,
+		Output:          output,
+	})
+	return runner, err
+}
+
+// newEnvironment returns the environment detected on first use, with overrides of options.
+// Versions of the node are read from the cluster of kube if it's reachable.
+func newEnvironment(opts *Options, kube *kubeClients) env.Environment {
+	envOpts := opts.envOptions()
+	envOpts.KubeClient = kube.client
+	return env.NewEnvironment(envOpts)
 }
 
 func buildToolContext(opts *Options) (*base.ToolContext, error) {
@@ -141,13 +360,22 @@ func buildToolContext(opts *Options) (*base.ToolContext, error) {
 	if opts.Help {
 		opts.RemainingArgs = append(opts.RemainingArgs, "-h")
 	}
+	// Add back args shared with tools, e.g. netexec --pod and --namespace
+	if opts.Pod != "" {
+		opts.RemainingArgs = append(opts.RemainingArgs, "--pod", opts.Pod)
+	}
+	for _, ns := range opts.Namespaces {
+		opts.RemainingArgs = append(opts.RemainingArgs, "--namespace", ns)
+	}
 	log.WithFields(log.Fields{"args": opts.RemainingArgs}).Debug("Tool context")
-	ctx := &base.ToolContext{
-		Args:        opts.RemainingArgs,
-		Environment: env.GetEnvironment(),
+	kube, err := buildKubeClients(opts)
+	if err != nil {
+		kube = &kubeClients{}
 	}
-	if _, configFlags, err := buildKubeClient(opts.KubeMasterUrl, opts.KubeConfigPath); err == nil {
-		ctx.KubeConfigFlag = configFlags
+	ctx := &base.ToolContext{
+		Args:           opts.RemainingArgs,
+		Environment:    newEnvironment(opts, kube),
+		KubeConfigFlag: kube.configFlags,
 	}
 	return ctx, nil
 }
@@ -163,8 +391,6 @@ func main() {
 	}
 	opts.RemainingArgs = remainingArgs
 
-	processOptions(&opts)
-
 	if len(opts.Verbose) > 0 {
 		if opts.Verbose == "none" {
 			logrus.SetOutput(ioutil.Discard)
@@ -177,13 +403,17 @@ func main() {
 		}
 	}
 
+	if err := processOptions(&opts); err != nil {
+		log.Fatal(err)
+	}
+
 	if !isatty.IsTerminal(os.Stdout.Fd()) || opts.NoColor || opts.Output != "" {
 		color.NoColor = true
 	}
 
 	if opts.ListCheckers {
-		fmt.Print("checks: ")
-		fmt.Println(chks.ListAllCheckerNames())
+		fmt.Println("checks:")
+		printCheckers(os.Stdout)
 		fmt.Print("tools: ")
 		fmt.Println(tools.ListAllToolNames())
 		return
@@ -194,15 +424,6 @@ func main() {
 		return
 	}
 
-	var formatter formatters.Formatter
-	if opts.Format == "json" {
-		formatter = &formatters.JsonFormatter{}
-	} else if opts.Format == "oneline" {
-		formatter = &formatters.OneLineFormatter{}
-	} else {
-		formatter = &formatters.TextFormatter{}
-	}
-
 	// Tool Mode
 	if opts.IsToolMode() {
 		ctx, err := buildToolContext(&opts)
@@ -230,45 +451,94 @@ func main() {
 		return
 	}
 
-	// Prepare dependencies
-	ctx, err := buildCheckContext(&opts)
-	if err != nil {
-		log.Fatal(err)
+	// Clients and environment of each cluster are built by runClusters in multi-cluster mode
+	kube := &kubeClients{}
+	if !opts.IsMultiClusterMode() {
+		kube = loadKubeClients(&opts)
 	}
+	environment := newEnvironment(&opts, kube)
 
-	ctx.Output = os.Stdout
+	if opts.ShowEnv {
+		if err := printEnvironment(os.Stdout, environment, opts.Format); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var formatter formatters.Formatter
+	if opts.Format == "json" {
+		formatter = &formatters.JsonFormatter{}
+	} else if opts.Format == "markdown" {
+		formatter = &formatters.MarkdownFormatter{}
+	} else if opts.Format == "html" {
+		formatter = &formatters.HtmlFormatter{}
+	} else if opts.Format == "junit" {
+		formatter = &formatters.JUnitFormatter{}
+	} else if opts.Format == "oneline" {
+		formatter = &formatters.OneLineFormatter{}
+	} else {
+		formatter = &formatters.TextFormatter{}
+	}
+
+	// Prepare dependencies
+	var output io.Writer = os.Stdout
 	if opts.Output != "" {
 		outFile, err := os.OpenFile(opts.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
 			log.Fatalf("Fail to open output file: %s", opts.Output)
 		}
 		defer outFile.Close()
-		ctx.Output = outFile
+		output = outFile
 	}
 
-	// Batch mode
-	if opts.IsBatchMode() {
-		runBatch(&opts, ctx, formatter)
+	// Cancel in-flight checkers on interrupt so that results collected so far
+	// are still written out. A second interrupt kills the process.
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, opts.Timeout)
+		defer cancel()
+	}
+	go func() {
+		<-runCtx.Done()
+		stop()
+	}()
+
+	// Multi-cluster mode
+	if opts.IsMultiClusterMode() {
+		results, err := runClusters(runCtx, &opts, formatter, output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !opts.NoSetExitCode {
+			os.Exit(getBatchExitCode(batch.Summarize(results)))
+		}
 		return
 	}
 
-	// Check
-	results, err := chks.Check(ctx, opts.Checkers)
+	cfg, configData, err := loadConfig(&opts, kube)
+	if err != nil {
+		log.Fatal(err)
+	}
+	runner, err := buildRunner(&opts, environment, kube, cfg, formatter, output)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Output
-	err = formatter.WriteResults(ctx.Output, results)
+	// Batch mode
+	if opts.IsBatchMode() {
+		runBatch(&opts, runner, runner.CheckContext(runCtx), formatter, configData)
+		return
+	}
+
+	// Check and output
+	results, err := runner.Run(runCtx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	if !opts.NoSetExitCode {
-		for _, r := range results {
-			if !r.Ok() {
-				os.Exit(1)
-			}
-		}
+		os.Exit(getExitCode(base.Summarize(results)))
 	}
 }
@@ -28,8 +28,11 @@ TimeoutSec=60
 WantedBy=multi-user.target
 `
 	OutputFile = "/tmp/kdebug.stdout.log"
+	PluginDir  = "/tmp/kdebug-plugins"
 )
 
+// copyFile copies src to a temporary file next to dst and renames it to dst,
+// so that an existing dst is replaced as a whole even if it's running.
 func copyFile(src, dst string) error {
 	in, err := os.Open(src)
 	if err != nil {
@@ -37,17 +40,60 @@ func copyFile(src, dst string) error {
 	}
 	defer in.Close()
 
-	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY, 0755)
+	out, err := ioutil.TempFile(path.Dir(dst), "."+path.Base(dst)+".*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(out.Name())
 	defer out.Close()
 
 	if _, err = io.Copy(out, in); err != nil {
 		return err
 	}
+	if err = out.Chmod(0755); err != nil {
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dst)
+}
 
-	return out.Sync()
+// systemdQuote quotes an arg of ExecStart so that systemd neither splits it
+// nor expands % specifiers and $ environment variables in it.
+func systemdQuote(arg string) string {
+	arg = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"%", "%%",
+		"$", "$$",
+	).Replace(arg)
+	return `"` + arg + `"`
+}
+
+// copyPlugins copies plugins in src dir to host. Hidden entries like
+// ..data of a mounted config map are skipped.
+func copyPlugins(src string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(PluginDir, 0755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if err = copyFile(path.Join(src, entry.Name()), path.Join(PluginDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func writeSystemdUnit(cmd string) error {
@@ -86,8 +132,22 @@ func main() {
 		log.Fatalf("fail to copy file: %+v", err)
 	}
 
+	// Copy plugins to host
+	for i := 0; i+1 < len(cmdArgs); i++ {
+		if cmdArgs[i] == "--plugin-dir" {
+			if err := copyPlugins(cmdArgs[i+1]); err != nil {
+				log.Fatalf("fail to copy plugins: %+v", err)
+			}
+			cmdArgs[i+1] = PluginDir
+		}
+	}
+
 	// Set up system config
-	dstCmd := dstPath + " " + strings.Join(cmdArgs, " ")
+	quoted := []string{systemdQuote(dstPath)}
+	for _, arg := range cmdArgs {
+		quoted = append(quoted, systemdQuote(arg))
+	}
+	dstCmd := strings.Join(quoted, " ")
 	if err := writeSystemdUnit(dstCmd); err != nil {
 		log.Fatalf("fail to write unit file: %+v", err)
 	}
@@ -131,6 +191,10 @@ func main() {
 		log.Fatalf("fail to remove stdout file: %+v", err)
 	}
 
+	if err = os.RemoveAll(PluginDir); err != nil {
+		log.Fatalf("fail to remove plugins: %+v", err)
+	}
+
 	// Output
 	os.Stdout.Write(output)
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"-c":                              `"-c"`,
+		"http:url=https://foo/a%20b?x=$y": `"http:url=https://foo/a%%20b?x=$$y"`,
+		`say "hi" \ there`:                `"say \"hi\" \\ there"`,
+	}
+	for arg, expected := range tests {
+		if quoted := systemdQuote(arg); quoted != expected {
+			t.Errorf("Expect %s but got %s", expected, quoted)
+		}
+	}
+}
+
+func TestCopyFileReplaces(t *testing.T) {
+	dir := t.TempDir()
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	os.WriteFile(dst, []byte("a longer old plugin"), 0755)
+	os.WriteFile(src, []byte("new"), 0644)
+
+	if err := copyFile(src, dst); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	data, _ := os.ReadFile(dst)
+	if string(data) != "new" {
+		t.Errorf("Expect dst replaced but got %q", data)
+	}
+	if info, _ := os.Stat(dst); info.Mode().Perm() != 0755 {
+		t.Errorf("Expect executable dst but got %s", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("Expect no temporary files left but got %d entries", len(entries))
+	}
+}
@@ -1,25 +1,95 @@
 package base
 
 import (
+	"context"
 	"io"
+	"time"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 
+	"github.com/Azure/kdebug/pkg/config"
 	"github.com/Azure/kdebug/pkg/env"
+	"github.com/Azure/kdebug/pkg/kubecache"
 )
 
+// PodRef identifies a pod.
+type PodRef struct {
+	Name      string
+	Namespace string
+}
+
+func (p PodRef) String() string {
+	return p.Namespace + "/" + p.Name
+}
+
 type CheckContext struct {
-	// TODO: Add user input here
-	Pod struct {
-		Name      string
-		Namespace string
-	}
+	// Pod is the pod to diagnose, given with --pod.
+	Pod PodRef
 
 	// TODO: Add shared dependencies here, for example, kube-client
-	Environment env.Environment
-	KubeClient  *kubernetes.Clientset
-	Output      io.Writer
+	Environment   env.Environment
+	KubeClient    kubernetes.Interface
+	DynamicClient dynamic.Interface
+	// KubeConfig is the REST config the clients are built with.
+	KubeConfig *rest.Config
+	// KubeCache shares objects listed by Kubernetes checkers so that each is fetched once per run.
+	KubeCache *kubecache.Cache
+	Output    io.Writer
+
+	// Context is cancelled when the run is interrupted or a checker runs out of time.
+	// Checkers should pass it to blocking calls. Use Ctx() to read it.
+	Context context.Context
+	// Timeout is the default time budget of a single checker. Zero means no limit.
+	Timeout time.Duration
+	// CheckerTimeouts overrides Timeout for checkers by name.
+	CheckerTimeouts map[string]time.Duration
+	// Parallelism is the max number of checkers running at the same time.
+	Parallelism int
+	// CheckerParams are parameters given on the command line by checker name,
+	// e.g. -c dns:server=10.0.0.10.
+	CheckerParams map[string]map[string]string
+	// Params are the parameters of the running checker.
+	Params map[string]string
+	// CheckerConfigs are sections of the config file by checker name.
+	CheckerConfigs map[string]*config.Section
+	// Config is the config section of the running checker, nil if not configured.
+	Config *config.Section
+}
+
+// Ctx returns Context, or context.Background() if it is not set.
+func (c *CheckContext) Ctx() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
+// TimeoutOf returns the time budget of checker with given name.
+func (c *CheckContext) TimeoutOf(name string) time.Duration {
+	if t, ok := c.CheckerTimeouts[name]; ok {
+		return t
+	}
+	return c.Timeout
+}
+
+// CheckerMetadata describes a checker and what it requires to run.
+// Checkers whose requirements are not met are reported as skipped.
+type CheckerMetadata struct {
+	Description string
+	// RequiredFlags are environment flags that must be present, e.g. "linux".
+	RequiredFlags   []string
+	NeedsKubeClient bool
+	NeedsRoot       bool
+	// NeedsPod checkers diagnose the pod of CheckContext.Pod.
+	NeedsPod bool
+	// Exclusive checkers run alone, e.g. because they sample system wide CPU usage.
+	Exclusive bool
+	Tags      []string
+	// Parameters are names and descriptions of parameters accepted on the command line.
+	Parameters map[string]string
 }
 
 type ToolContext struct {
@@ -29,15 +99,118 @@ type ToolContext struct {
 	KubeConfigFlag *genericclioptions.ConfigFlags
 }
 
+type Status string
+
+const (
+	StatusPass    Status = "pass"
+	StatusWarn    Status = "warn"
+	StatusFail    Status = "fail"
+	StatusSkipped Status = "skipped"
+	StatusErrored Status = "errored"
+)
+
+// Valid returns whether s is a known status. Empty is valid since it's derived from Error.
+func (s Status) Valid() bool {
+	switch s {
+	case "", StatusPass, StatusWarn, StatusFail, StatusSkipped, StatusErrored:
+		return true
+	}
+	return false
+}
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// Valid returns whether s is a known severity. Empty is valid since it's derived from the status.
+func (s Severity) Valid() bool {
+	switch s {
+	case "", SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// CheckResult is a result of a checker. Reports written before schema version 2
+// have PascalCase keys, which are still read since keys are matched case-insensitively.
 type CheckResult struct {
-	Checker         string
-	Error           string
-	Description     string
-	Recommendations []string
-	Logs            []string
-	HelpLinks       []string
+	Checker         string   `json:"checker"`
+	Status          Status   `json:"status"`
+	Severity        Severity `json:"severity"`
+	Error           string   `json:"error"`
+	Description     string   `json:"description"`
+	Recommendations []string `json:"recommendations"`
+	Logs            []string `json:"logs"`
+	HelpLinks       []string `json:"helpLinks"`
+	// Duration is how long the checker producing the result took, in nanoseconds in JSON.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// GetStatus returns Status, or derives it from Error for results that don't set it.
+func (r *CheckResult) GetStatus() Status {
+	if r.Status != "" {
+		return r.Status
+	}
+	if r.Error == "" {
+		return StatusPass
+	}
+	return StatusFail
+}
+
+// GetSeverity returns Severity, or the default severity of the result status.
+func (r *CheckResult) GetSeverity() Severity {
+	if r.Severity != "" {
+		return r.Severity
+	}
+	switch r.GetStatus() {
+	case StatusFail:
+		return SeverityHigh
+	case StatusWarn, StatusErrored:
+		return SeverityMedium
+	default:
+		return SeverityInfo
+	}
 }
 
+// Ok returns true if the result does not indicate a problem.
 func (r *CheckResult) Ok() bool {
-	return r.Error == ""
+	s := r.GetStatus()
+	return s == StatusPass || s == StatusSkipped
+}
+
+type Summary struct {
+	Pass    int
+	Warn    int
+	Fail    int
+	Skipped int
+	Errored int
+}
+
+func Summarize(results []*CheckResult) Summary {
+	var s Summary
+	for _, r := range results {
+		switch r.GetStatus() {
+		case StatusPass:
+			s.Pass++
+		case StatusWarn:
+			s.Warn++
+		case StatusFail:
+			s.Fail++
+		case StatusSkipped:
+			s.Skipped++
+		case StatusErrored:
+			s.Errored++
+		}
+	}
+	return s
+}
+
+func (s Summary) Problems() int {
+	return s.Warn + s.Fail + s.Errored
 }
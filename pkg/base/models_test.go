@@ -0,0 +1,42 @@
+package base
+
+import "testing"
+
+func TestCheckResultStatus(t *testing.T) {
+	r := &CheckResult{}
+	if r.GetStatus() != StatusPass || r.GetSeverity() != SeverityInfo || !r.Ok() {
+		t.Errorf("Expect result without error to pass but got %+v", r)
+	}
+
+	r = &CheckResult{Error: "err"}
+	if r.GetStatus() != StatusFail || r.GetSeverity() != SeverityHigh || r.Ok() {
+		t.Errorf("Expect result with error to fail but got %+v", r)
+	}
+
+	r = &CheckResult{Status: StatusWarn, Error: "err", Severity: SeverityLow}
+	if r.GetStatus() != StatusWarn || r.GetSeverity() != SeverityLow || r.Ok() {
+		t.Errorf("Expect warning result but got %+v", r)
+	}
+
+	r = &CheckResult{Status: StatusSkipped}
+	if !r.Ok() {
+		t.Errorf("Expect skipped result to be ok")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]*CheckResult{
+		{},
+		{Error: "err"},
+		{Status: StatusWarn},
+		{Status: StatusSkipped},
+		{Status: StatusErrored},
+	})
+	expected := Summary{Pass: 1, Warn: 1, Fail: 1, Skipped: 1, Errored: 1}
+	if s != expected {
+		t.Errorf("Expect %+v but got %+v", expected, s)
+	}
+	if s.Problems() != 3 {
+		t.Errorf("Expect 3 problems but got %d", s.Problems())
+	}
+}
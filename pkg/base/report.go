@@ -0,0 +1,80 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/Azure/kdebug/pkg/env"
+)
+
+// ReportSchemaVersion is bumped when fields of Report change incompatibly.
+const ReportSchemaVersion = 2
+
+// Report is the JSON output of a run.
+type Report struct {
+	// SchemaVersion is 0 for reports written by older versions.
+	SchemaVersion int `json:"schemaVersion"`
+	// Revision is the VCS revision kdebug is built from.
+	Revision  string    `json:"revision,omitempty"`
+	Hostname  string    `json:"hostname,omitempty"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	// Environment is where the results are produced.
+	Environment *env.Facts     `json:"environment,omitempty"`
+	Results     []*CheckResult `json:"results"`
+}
+
+// NewReport wraps results of a run ending now.
+func NewReport(results []*CheckResult) *Report {
+	now := time.Now()
+	report := &Report{
+		SchemaVersion: ReportSchemaVersion,
+		Revision:      BuildRevision(),
+		StartTime:     now,
+		EndTime:       now,
+		Results:       results,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		report.Hostname = hostname
+	}
+	if report.Results == nil {
+		report.Results = []*CheckResult{}
+	}
+	return report
+}
+
+// BuildRevision returns the VCS revision kdebug is built from, or empty if unknown.
+func BuildRevision() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
+
+// DecodeReport decodes a report, or bare results written by older versions.
+func DecodeReport(data []byte) (*Report, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var results []*CheckResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, err
+		}
+		return &Report{Results: results}, nil
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	if report.SchemaVersion > ReportSchemaVersion {
+		return nil, fmt.Errorf("Unsupported report schema version %d. Upgrade kdebug to read it", report.SchemaVersion)
+	}
+	return &report, nil
+}
@@ -0,0 +1,65 @@
+package base
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeReport(t *testing.T) {
+	report, err := DecodeReport([]byte(`{"environment": {"os": "linux", "distro": "ubuntu"}, "results": [{"Checker": "Dns"}]}`))
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if report.Environment == nil || report.Environment.Distro != "ubuntu" || len(report.Results) != 1 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	// Written by older versions
+	report, err = DecodeReport([]byte(` [{"Checker": "Dns"}, {"Checker": "Http"}]`))
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if report.Environment != nil || len(report.Results) != 2 || report.Results[1].Checker != "Http" {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	// Results of schema version 1 have PascalCase keys
+	report, err = DecodeReport([]byte(`{"schemaVersion": 1, "results": [{"Checker": "Dns", "Status": "warn", "HelpLinks": ["https://example.com"], "Duration": 1000000}]}`))
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if r := report.Results[0]; r.Checker != "Dns" || r.Status != StatusWarn || len(r.HelpLinks) != 1 || r.Duration != time.Millisecond {
+		t.Errorf("Unexpected result of schema version 1: %+v", r)
+	}
+
+	if _, err := DecodeReport([]byte("not json")); err == nil {
+		t.Errorf("Expect error of invalid report")
+	}
+	if _, err := DecodeReport([]byte(`{"schemaVersion": 99, "results": []}`)); err == nil {
+		t.Errorf("Expect error of newer schema version")
+	}
+}
+
+func TestReportRoundTrip(t *testing.T) {
+	report := NewReport([]*CheckResult{{Checker: "Dns", Duration: 2 * time.Second}})
+	report.StartTime = report.EndTime.Add(-time.Minute)
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if !strings.Contains(string(data), `{"checker":"Dns","status":"","severity":"","error":"",`) ||
+		!strings.Contains(string(data), `"duration":2000000000}`) {
+		t.Errorf("Expect camelCase keys of results but got %s", data)
+	}
+
+	decoded, err := DecodeReport(data)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if decoded.SchemaVersion != ReportSchemaVersion || decoded.Hostname != report.Hostname ||
+		!decoded.StartTime.Equal(report.StartTime) || decoded.Results[0].Duration != 2*time.Second {
+		t.Errorf("Expect %+v but got %+v", report, decoded)
+	}
+}
@@ -1,10 +1,23 @@
 package batch
 
-import "github.com/Azure/kdebug/pkg/base"
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/env"
+)
 
 type BatchOptions struct {
-	Machines    []string
-	Checkers    []string
+	Machines []string
+	Checkers []string
+	Config   []byte
+	// Plugins are files of plugin checkers to copy to remote machines.
+	Plugins []string
+	// Checks are definitions of declarative checkers.
+	Checks []byte
+	// Env overrides detection of environments of remote machines.
+	Env         env.Options
 	Concurrency int
 	Reporter    BatchReportor
 }
@@ -12,15 +25,67 @@ type BatchOptions struct {
 type batchTask struct {
 	Machine  string
 	Checkers []string
+	Config   []byte
+	Checks   []byte
+	Plugins  []string
+	// PluginDir is where plugins are available on the remote machine.
+	PluginDir string
+	Env       env.Options
+}
+
+// kdebugArgs returns the arguments to run the task with kdebug on a remote machine.
+func (t *batchTask) kdebugArgs() []string {
+	args := []string{"-f", "json", "--no-set-exit-code"}
+	for _, checker := range t.Checkers {
+		args = append(args, "-c", checker)
+	}
+	if len(t.Config) > 0 {
+		args = append(args, "--config-data", base64.StdEncoding.EncodeToString(t.Config))
+	}
+	if len(t.Checks) > 0 {
+		args = append(args, "--checks-data", base64.StdEncoding.EncodeToString(t.Checks))
+	}
+	if t.PluginDir != "" {
+		args = append(args, "--plugin-dir", t.PluginDir)
+	}
+	for _, flag := range t.Env.Flags {
+		args = append(args, "--env-flag", flag)
+	}
+	if t.Env.NoDetect {
+		args = append(args, "--no-detect")
+	}
+	return args
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 type BatchResult struct {
-	Machine      string
+	Machine string
+	// Cluster is the kubeconfig context of results of multi-cluster runs, which have no machine.
+	Cluster      string
 	Error        error
 	CheckResults []*base.CheckResult
+	// Report is the output of kdebug on the machine, including CheckResults.
+	// Metadata is unknown for older versions of kdebug.
+	Report *base.Report
+}
+
+// decodeResults decodes JSON output of kdebug into the result.
+func (r *BatchResult) decodeResults(data []byte) error {
+	report, err := base.DecodeReport(data)
+	if err != nil {
+		return err
+	}
+	r.CheckResults = report.Results
+	r.Report = report
+	return nil
 }
 
 type BatchExecutor interface {
+	// Name is the name of the executor in reports, e.g. ssh.
+	Name() string
 	Execute(opts *BatchOptions) ([]*BatchResult, error)
 }
 
@@ -0,0 +1,52 @@
+package batch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Azure/kdebug/pkg/env"
+)
+
+func TestKdebugArgs(t *testing.T) {
+	task := &batchTask{
+		Checkers:  []string{"dns", "http:url=https://foo/?a=1&b=2"},
+		Config:    []byte("checkers: {}"),
+		PluginDir: "/tmp/kdebug-plugins",
+		Env:       env.Options{Flags: []string{"azure"}, NoDetect: true},
+	}
+	expected := []string{
+		"-f", "json", "--no-set-exit-code",
+		"-c", "dns",
+		"-c", "http:url=https://foo/?a=1&b=2",
+		"--config-data", "Y2hlY2tlcnM6IHt9",
+		"--plugin-dir", "/tmp/kdebug-plugins",
+		"--env-flag", "azure",
+		"--no-detect",
+	}
+	if args := task.kdebugArgs(); !reflect.DeepEqual(args, expected) {
+		t.Errorf("Unexpected args: %v", args)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if s := shellQuote("a&b"); s != "'a&b'" {
+		t.Errorf("Unexpected quoted string: %s", s)
+	}
+	if s := shellQuote("it's"); s != `'it'\''s'` {
+		t.Errorf("Unexpected quoted string: %s", s)
+	}
+}
+
+func TestDecodeResults(t *testing.T) {
+	var result BatchResult
+	err := result.decodeResults([]byte(`{"schemaVersion": 1, "hostname": "vm-1", "results": [{"Checker": "Dns"}]}`))
+	if err != nil || len(result.CheckResults) != 1 || result.Report.Hostname != "vm-1" {
+		t.Errorf("Unexpected result: %+v, %v", result, err)
+	}
+
+	// Output of older versions
+	err = result.decodeResults([]byte(`[{"Checker": "Dns"}, {"Checker": "Http"}]`))
+	if err != nil || len(result.CheckResults) != 2 || result.Report.SchemaVersion != 0 {
+		t.Errorf("Unexpected result: %+v, %v", result, err)
+	}
+}
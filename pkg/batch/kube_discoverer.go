@@ -11,12 +11,12 @@ import (
 )
 
 type KubeBatchDiscoverer struct {
-	client        *kubernetes.Clientset
+	client        kubernetes.Interface
 	labelSelector string
 	unready       bool
 }
 
-func NewKubeBatchDiscoverer(client *kubernetes.Clientset, labelSelector string, unready bool) *KubeBatchDiscoverer {
+func NewKubeBatchDiscoverer(client kubernetes.Interface, labelSelector string, unready bool) *KubeBatchDiscoverer {
 	return &KubeBatchDiscoverer{
 		client:        client,
 		labelSelector: labelSelector,
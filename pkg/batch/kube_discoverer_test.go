@@ -1,9 +1,12 @@
 package batch
 
 import (
+	"reflect"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestMatchNode(t *testing.T) {
@@ -42,3 +45,31 @@ func TestMatchNode(t *testing.T) {
 		t.Errorf("Expect matchNode == false when specifying unready and node is ready but got true")
 	}
 }
+
+func TestKubeBatchDiscoverer(t *testing.T) {
+	node := func(name string, ready corev1.ConditionStatus, labels map[string]string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+			},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		node("n1", corev1.ConditionTrue, map[string]string{"role": "agent"}),
+		node("n2", corev1.ConditionFalse, map[string]string{"role": "agent"}),
+		node("n3", corev1.ConditionFalse, nil),
+	)
+
+	machines, err := NewKubeBatchDiscoverer(client, "", false).Discover()
+	if err != nil || !reflect.DeepEqual(machines, []string{"n1", "n2", "n3"}) {
+		t.Errorf("Expect all nodes but got %v, %v", machines, err)
+	}
+	machines, err = NewKubeBatchDiscoverer(client, "role=agent", true).Discover()
+	if err != nil || !reflect.DeepEqual(machines, []string{"n2"}) {
+		t.Errorf("Expect unready agent nodes but got %v, %v", machines, err)
+	}
+	if _, err := NewKubeBatchDiscoverer(nil, "", false).Discover(); err == nil {
+		t.Errorf("Expect error without client")
+	}
+}
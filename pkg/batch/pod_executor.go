@@ -2,9 +2,11 @@ package batch
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -15,19 +17,26 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// PodPluginDir is where plugins are mounted in the pod.
+const PodPluginDir = "/kdebug-plugins"
+
 type PodBatchExecutor struct {
-	Client    *kubernetes.Clientset
+	Client    kubernetes.Interface
 	Image     string
 	Namespace string
 	Mode      string
+
+	pollInterval time.Duration
 }
 
-func NewPodBatchExecutor(kubeClient *kubernetes.Clientset, image, ns, mode string) *PodBatchExecutor {
+func NewPodBatchExecutor(kubeClient kubernetes.Interface, image, ns, mode string) *PodBatchExecutor {
 	e := &PodBatchExecutor{
 		Client:    kubeClient,
 		Image:     image,
 		Namespace: ns,
 		Mode:      mode,
+
+		pollInterval: 5 * time.Second,
 	}
 
 	log.WithFields(log.Fields{
@@ -55,6 +64,10 @@ func (e *PodBatchExecutor) isJobCompleted(job *batchv1.Job) bool {
 	return false
 }
 
+func (e *PodBatchExecutor) Name() string {
+	return "pod"
+}
+
 func (e *PodBatchExecutor) Execute(opts *BatchOptions) ([]*BatchResult, error) {
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
@@ -72,6 +85,15 @@ func (e *PodBatchExecutor) Execute(opts *BatchOptions) ([]*BatchResult, error) {
 	resultChan := make(chan *BatchResult, opts.Concurrency)
 	runName := e.generateRunName()
 
+	pluginDir := ""
+	if len(opts.Plugins) > 0 {
+		if err := e.createPluginConfigMap(runName, opts.Plugins); err != nil {
+			return nil, err
+		}
+		defer e.deletePluginConfigMap(runName)
+		pluginDir = PodPluginDir
+	}
+
 	for i := 0; i < opts.Concurrency; i++ {
 		go e.startWorker(runName, taskChan, resultChan)
 	}
@@ -79,8 +101,12 @@ func (e *PodBatchExecutor) Execute(opts *BatchOptions) ([]*BatchResult, error) {
 	for _, machine := range opts.Machines {
 		go func(m string) {
 			taskChan <- &batchTask{
-				Machine:  m,
-				Checkers: opts.Checkers,
+				Machine:   m,
+				Checkers:  opts.Checkers,
+				Config:    opts.Config,
+				Checks:    opts.Checks,
+				PluginDir: pluginDir,
+				Env:       opts.Env,
 			}
 		}(machine)
 	}
@@ -97,6 +123,56 @@ func (e *PodBatchExecutor) Execute(opts *BatchOptions) ([]*BatchResult, error) {
 	return results, nil
 }
 
+// createPluginConfigMap stores plugins in a config map to be mounted by pods.
+func (e *PodBatchExecutor) createPluginConfigMap(name string, files []string) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: e.Namespace,
+		},
+		BinaryData: map[string][]byte{},
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("Fail to read plugin %s: %s", file, err)
+		}
+		cm.BinaryData[filepath.Base(file)] = data
+	}
+	_, err := e.Client.CoreV1().ConfigMaps(e.Namespace).Create(
+		context.Background(), cm, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Fail to create config map %s for plugins: %s", name, err)
+	}
+	return nil
+}
+
+func (e *PodBatchExecutor) deletePluginConfigMap(name string) {
+	err := e.Client.CoreV1().ConfigMaps(e.Namespace).Delete(
+		context.Background(), name, metav1.DeleteOptions{})
+	if err != nil {
+		log.Warnf("Fail to delete config map %s of plugins: %s", name, err)
+	}
+}
+
+func addPluginVolume(spec *corev1.PodTemplateSpec, configMapName string) {
+	mode := int32(0755)
+	spec.Spec.Volumes = append(spec.Spec.Volumes, corev1.Volume{
+		Name: "plugins",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+				DefaultMode:          &mode,
+			},
+		},
+	})
+	spec.Spec.Containers[0].VolumeMounts = append(spec.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{
+			Name:      "plugins",
+			MountPath: PodPluginDir,
+		})
+}
+
 func (e *PodBatchExecutor) startWorker(runName string, taskChan chan *batchTask, resultChan chan *BatchResult) {
 	for task := range taskChan {
 		resultChan <- e.executeTask(runName, task)
@@ -196,16 +272,7 @@ func (e *PodBatchExecutor) executeTask(runName string, task *batchTask) *BatchRe
 	}
 
 	// Create job
-	cmd := []string{
-		"/kdebug",
-		"-f", "json",
-		"--no-set-exit-code",
-		"-v", "none",
-	}
-	for _, checker := range task.Checkers {
-		cmd = append(cmd, "-c")
-		cmd = append(cmd, checker)
-	}
+	cmd := append([]string{"/kdebug", "-v", "none"}, task.kdebugArgs()...)
 
 	ttl := int32(300)
 	backoff := int32(0)
@@ -228,6 +295,9 @@ func (e *PodBatchExecutor) executeTask(runName string, task *batchTask) *BatchRe
 	} else {
 		job.Spec.Template = e.getPodTemplateSpecContainerMode(cmd, task.Machine)
 	}
+	if task.PluginDir != "" {
+		addPluginVolume(&job.Spec.Template, runName)
+	}
 
 	job, err := e.Client.BatchV1().Jobs(e.Namespace).Create(
 		context.Background(), job, metav1.CreateOptions{})
@@ -240,7 +310,7 @@ func (e *PodBatchExecutor) executeTask(runName string, task *batchTask) *BatchRe
 	timeout := 5 * time.Minute
 	startTime := time.Now()
 	for {
-		time.Sleep(5 * time.Second)
+		time.Sleep(e.pollInterval)
 
 		job, err := e.Client.BatchV1().Jobs(e.Namespace).Get(
 			context.Background(), job.Name, metav1.GetOptions{})
@@ -279,9 +349,12 @@ func (e *PodBatchExecutor) executeTask(runName string, task *batchTask) *BatchRe
 	}
 	defer logs.Close()
 
-	decoder := json.NewDecoder(logs)
-
-	result.Error = decoder.Decode(&result.CheckResults)
+	output, err := io.ReadAll(logs)
+	if err != nil {
+		result.Error = fmt.Errorf("fail to read logs of pod %s: %+v", pod.Name, err)
+		return result
+	}
+	result.Error = result.decodeResults(output)
 
 	return result
 }
@@ -0,0 +1,91 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeReporter struct {
+	results []*BatchResult
+}
+
+func (r *fakeReporter) OnResult(result *BatchResult) {
+	r.results = append(r.results, result)
+}
+
+func TestPodBatchExecutor(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	// Complete jobs immediately and create their pods
+	client.PrependReactor("create", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		job := action.(k8stesting.CreateAction).GetObject().(*batchv1.Job)
+		job.Status.Conditions = []batchv1.JobCondition{{Type: "Complete", Status: "True"}}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Name + "-pod",
+				Namespace: job.Namespace,
+				Labels:    map[string]string{"job-name": job.Name},
+			},
+		}
+		return false, nil, client.Tracker().Add(pod)
+	})
+
+	plugin := filepath.Join(t.TempDir(), "myplugin")
+	os.WriteFile(plugin, []byte("#!/bin/sh\n"), 0755)
+
+	e := NewPodBatchExecutor(client, "kdebug:test", "kdebug", "container")
+	e.pollInterval = time.Millisecond
+	reporter := &fakeReporter{}
+	results, err := e.Execute(&BatchOptions{
+		Machines:    []string{"node1"},
+		Checkers:    []string{"dns"},
+		Plugins:     []string{plugin},
+		Concurrency: 1,
+		Reporter:    reporter,
+	})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 1 || len(reporter.results) != 1 || results[0].Machine != "node1" {
+		t.Fatalf("Expect 1 result of node1 but got %+v", results)
+	}
+	// Fake client streams "fake logs" which is not JSON
+	if results[0].Error == nil {
+		t.Errorf("Expect error decoding fake logs")
+	}
+
+	jobs, _ := client.BatchV1().Jobs("kdebug").List(context.Background(), metav1.ListOptions{})
+	if len(jobs.Items) != 1 {
+		t.Fatalf("Expect 1 job but got %d", len(jobs.Items))
+	}
+	spec := jobs.Items[0].Spec.Template.Spec
+	if spec.NodeName != "node1" || spec.Containers[0].Image != "kdebug:test" {
+		t.Errorf("Unexpected pod spec: %+v", spec)
+	}
+	cmd := strings.Join(spec.Containers[0].Command, " ")
+	if !strings.HasPrefix(cmd, "/kdebug ") || !strings.Contains(cmd, "-c dns") ||
+		!strings.Contains(cmd, "--plugin-dir "+PodPluginDir) {
+		t.Errorf("Unexpected command: %s", cmd)
+	}
+	if len(spec.Volumes) != 1 || spec.Volumes[0].ConfigMap == nil {
+		t.Errorf("Expect plugin volume but got %+v", spec.Volumes)
+	}
+
+	cms, _ := client.CoreV1().ConfigMaps("kdebug").List(context.Background(), metav1.ListOptions{})
+	if len(cms.Items) != 0 {
+		t.Errorf("Expect plugin config map deleted but got %d", len(cms.Items))
+	}
+	if _, err := client.CoreV1().Namespaces().Get(context.Background(), "kdebug", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expect namespace created but got %s", err)
+	}
+}
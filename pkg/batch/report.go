@@ -0,0 +1,135 @@
+package batch
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/env"
+)
+
+// BatchRun is a run of checkers on machines, or on clusters of several kubeconfig contexts.
+type BatchRun struct {
+	StartTime time.Time
+	EndTime   time.Time
+	// Checkers are specs of checkers run on each machine, e.g. dns:server=10.0.0.10.
+	Checkers []string
+	// Executor runs checkers on machines, i.e. ssh or pod, or cluster for runs on clusters.
+	Executor string
+	Results  []*BatchResult
+}
+
+// Report is the JSON output of a batch run.
+type Report struct {
+	// SchemaVersion is the same as of the report of a single machine, base.ReportSchemaVersion.
+	SchemaVersion int `json:"schemaVersion"`
+	// Revision is the VCS revision of kdebug running the batch.
+	Revision  string           `json:"revision,omitempty"`
+	StartTime time.Time        `json:"startTime"`
+	EndTime   time.Time        `json:"endTime"`
+	Checkers  []string         `json:"checkers"`
+	Executor  string           `json:"executor"`
+	Summary   ReportSummary    `json:"summary"`
+	Machines  []*MachineReport `json:"machines"`
+}
+
+// ReportSummary counts machines and check results of all machines.
+type ReportSummary struct {
+	Machines int `json:"machines"`
+	// MachinesErrored failed to run kdebug, e.g. unreachable by ssh.
+	MachinesErrored int `json:"machinesErrored"`
+	Pass            int `json:"pass"`
+	Warn            int `json:"warn"`
+	Fail            int `json:"fail"`
+	Skipped         int `json:"skipped"`
+	Errored         int `json:"errored"`
+}
+
+// Checks returns counts of check results, without machines that failed to run.
+func (s ReportSummary) Checks() base.Summary {
+	return base.Summary{Pass: s.Pass, Warn: s.Warn, Fail: s.Fail, Skipped: s.Skipped, Errored: s.Errored}
+}
+
+// MachineReport is results of a machine, or of a cluster in runs on clusters.
+type MachineReport struct {
+	Machine string `json:"machine,omitempty"`
+	Cluster string `json:"cluster,omitempty"`
+	// Error is why kdebug failed to run. Results are empty if set.
+	Error string `json:"error,omitempty"`
+	// Hostname, Revision, StartTime and Environment are of kdebug on the
+	// machine. They are unknown for older versions of kdebug.
+	Hostname    string              `json:"hostname,omitempty"`
+	Revision    string              `json:"revision,omitempty"`
+	StartTime   *time.Time          `json:"startTime,omitempty"`
+	EndTime     *time.Time          `json:"endTime,omitempty"`
+	Environment *env.Facts          `json:"environment,omitempty"`
+	Results     []*base.CheckResult `json:"results"`
+}
+
+// NewReport builds the report of the run with machines sorted by name.
+func NewReport(run *BatchRun) *Report {
+	report := &Report{
+		SchemaVersion: base.ReportSchemaVersion,
+		Revision:      base.BuildRevision(),
+		StartTime:     run.StartTime,
+		EndTime:       run.EndTime,
+		Checkers:      run.Checkers,
+		Executor:      run.Executor,
+		Machines:      make([]*MachineReport, 0, len(run.Results)),
+	}
+	if report.Checkers == nil {
+		report.Checkers = []string{}
+	}
+
+	report.Summary = Summarize(run.Results)
+	for _, r := range run.Results {
+		m := &MachineReport{
+			Machine: r.Machine,
+			Cluster: r.Cluster,
+			Results: r.CheckResults,
+		}
+		if r.Report != nil {
+			m.Environment = r.Report.Environment
+			if r.Report.SchemaVersion > 0 {
+				m.Hostname = r.Report.Hostname
+				m.Revision = r.Report.Revision
+				m.StartTime = &r.Report.StartTime
+				m.EndTime = &r.Report.EndTime
+			}
+		}
+		if r.Error != nil {
+			m.Error = r.Error.Error()
+		}
+		if m.Results == nil {
+			m.Results = []*base.CheckResult{}
+		}
+		report.Machines = append(report.Machines, m)
+	}
+	sort.SliceStable(report.Machines, func(i, j int) bool {
+		a, b := report.Machines[i], report.Machines[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		return a.Machine < b.Machine
+	})
+	return report
+}
+
+// Summarize counts machines and check results of all machines. Machines that
+// fail to run are only counted in MachinesErrored since they have no results.
+func Summarize(results []*BatchResult) ReportSummary {
+	s := ReportSummary{Machines: len(results)}
+	for _, r := range results {
+		if r.Error != nil {
+			s.MachinesErrored++
+			continue
+		}
+		rs := base.Summarize(r.CheckResults)
+		s.Pass += rs.Pass
+		s.Warn += rs.Warn
+		s.Fail += rs.Fail
+		s.Skipped += rs.Skipped
+		s.Errored += rs.Errored
+	}
+	return s
+}
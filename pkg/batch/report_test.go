@@ -0,0 +1,58 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/kdebug/pkg/base"
+)
+
+func TestNewReport(t *testing.T) {
+	start := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	run := &BatchRun{
+		StartTime: start,
+		EndTime:   start.Add(time.Minute),
+		Checkers:  []string{"dns", "oom"},
+		Executor:  "ssh",
+		Results: []*BatchResult{
+			{
+				Machine: "node-1",
+				CheckResults: []*base.CheckResult{
+					{Checker: "Dns"},
+					{Checker: "OOM", Error: "Process killed"},
+				},
+				Report: &base.Report{SchemaVersion: 1, Hostname: "vm-1", StartTime: start, EndTime: start},
+			},
+			{Machine: "node-0", Error: errors.New("unreachable")},
+		},
+	}
+
+	report := NewReport(run)
+	if report.SchemaVersion != base.ReportSchemaVersion || report.Revision != base.BuildRevision() {
+		t.Errorf("Expect schema version %d and revision %q but got %d, %q",
+			base.ReportSchemaVersion, base.BuildRevision(), report.SchemaVersion, report.Revision)
+	}
+	if report.Machines[0].Machine != "node-0" || report.Machines[0].Error != "unreachable" {
+		t.Errorf("Expect machines sorted with errors as strings but got %+v", report.Machines[0])
+	}
+	if report.Machines[1].Hostname != "vm-1" || report.Machines[1].StartTime == nil {
+		t.Errorf("Expect report metadata of the machine but got %+v", report.Machines[1])
+	}
+	expected := ReportSummary{Machines: 2, MachinesErrored: 1, Pass: 1, Fail: 1}
+	if report.Summary != expected {
+		t.Errorf("Expect summary %+v but got %+v", expected, report.Summary)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	for _, s := range []string{`"schemaVersion":2`, `"executor":"ssh"`, `"error":"unreachable"`, `"results":[]`, `"startTime":"2023-01-02T10:00:00Z"`} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("Expect %s in JSON but got %s", s, data)
+		}
+	}
+}
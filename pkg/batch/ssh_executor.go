@@ -2,11 +2,12 @@ package batch
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"os/user"
+	"path"
+	"path/filepath"
 
 	scp "github.com/bramvdbogaerde/go-scp"
 	log "github.com/sirupsen/logrus"
@@ -14,6 +15,8 @@ import (
 	"golang.org/x/crypto/ssh/agent"
 )
 
+const RemotePluginDir = "/tmp/kdebug-plugins"
+
 type SshBatchExecutor struct {
 	User string
 }
@@ -32,6 +35,10 @@ func NewSshBatchExecutor(userName string) *SshBatchExecutor {
 	return e
 }
 
+func (e *SshBatchExecutor) Name() string {
+	return "ssh"
+}
+
 func (e *SshBatchExecutor) Execute(opts *BatchOptions) ([]*BatchResult, error) {
 	taskChan := make(chan *batchTask, opts.Concurrency)
 	resultChan := make(chan *BatchResult, opts.Concurrency)
@@ -45,6 +52,10 @@ func (e *SshBatchExecutor) Execute(opts *BatchOptions) ([]*BatchResult, error) {
 			taskChan <- &batchTask{
 				Machine:  m,
 				Checkers: opts.Checkers,
+				Config:   opts.Config,
+				Checks:   opts.Checks,
+				Plugins:  opts.Plugins,
+				Env:      opts.Env,
 			}
 		}(machine)
 	}
@@ -107,6 +118,16 @@ func (e *SshBatchExecutor) executeTask(task *batchTask) *BatchResult {
 		return result
 	}
 
+	if len(task.Plugins) > 0 {
+		log.Debugf("Copy plugins to %s", task.Machine)
+		err = copyPlugins(sshClient, task.Plugins)
+		if err != nil {
+			result.Error = fmt.Errorf("fail to copy plugins to remote machine: %+v", err)
+			return result
+		}
+		task.PluginDir = RemotePluginDir
+	}
+
 	sess, err := sshClient.NewSession()
 	if err != nil {
 		result.Error = fmt.Errorf("fail to create SSH session: %+v", err)
@@ -115,9 +136,9 @@ func (e *SshBatchExecutor) executeTask(task *batchTask) *BatchResult {
 	defer sess.Close()
 
 	// Execute command
-	cmd := fmt.Sprintf("/tmp/kdebug -f json --no-set-exit-code")
-	for _, c := range task.Checkers {
-		cmd += fmt.Sprintf(" -c %s", c)
+	cmd := "/tmp/kdebug"
+	for _, arg := range task.kdebugArgs() {
+		cmd += " " + shellQuote(arg)
 	}
 	log.Debugf("Execute kdebug on %s. Cmd: %s", task.Machine, cmd)
 	output, err := sess.Output(cmd)
@@ -128,7 +149,7 @@ func (e *SshBatchExecutor) executeTask(task *batchTask) *BatchResult {
 
 	// Build result
 	log.Debugf("Aggregate results from %s", task.Machine)
-	result.Error = json.Unmarshal(output, &result.CheckResults)
+	result.Error = result.decodeResults(output)
 	return result
 }
 
@@ -138,16 +159,39 @@ func copyExecutable(sshClient *ssh.Client) error {
 		return fmt.Errorf("fail to determine current executable location: %+v", err)
 	}
 
-	f, err := os.Open(path)
+	return copyFile(sshClient, path, "/tmp/kdebug")
+}
+
+func copyPlugins(sshClient *ssh.Client, files []string) error {
+	sess, err := sshClient.NewSession()
+	if err != nil {
+		return fmt.Errorf("fail to create SSH session: %+v", err)
+	}
+	defer sess.Close()
+	if err := sess.Run("mkdir -p " + RemotePluginDir); err != nil {
+		return fmt.Errorf("fail to create dir %s: %+v", RemotePluginDir, err)
+	}
+
+	for _, file := range files {
+		if err := copyFile(sshClient, file, path.Join(RemotePluginDir, filepath.Base(file))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(sshClient *ssh.Client, src, dst string) error {
+	f, err := os.Open(src)
 	if err != nil {
-		return fmt.Errorf("fail to open file %s: %+v", path, err)
+		return fmt.Errorf("fail to open file %s: %+v", src, err)
 	}
 	defer f.Close()
 
+	// A new SCP client per file since it closes the session after copying
 	scpClient, err := scp.NewClientBySSH(sshClient)
 	if err != nil {
 		return fmt.Errorf("fail to create SCP client: %+v", err)
 	}
 
-	return scpClient.CopyFromFile(context.Background(), *f, "/tmp/kdebug", "0755")
+	return scpClient.CopyFromFile(context.Background(), *f, dst, "0755")
 }
@@ -1,18 +1,31 @@
 package checker
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
 )
 
+const DefaultTimeout = 2 * time.Minute
+
 type Checker interface {
 	Name() string
+	Metadata() base.CheckerMetadata
 	Check(*base.CheckContext) ([]*base.CheckResult, error)
 }
 
+type checkOutput struct {
+	results []*base.CheckResult
+	err     error
+}
+
 func Check(ctx *base.CheckContext, checkerNames []string) ([]*base.CheckResult, error) {
 	checkers := make([]Checker, 0, len(checkerNames))
 
@@ -23,14 +36,163 @@ func Check(ctx *base.CheckContext, checkerNames []string) ([]*base.CheckResult,
 			return nil, errors.New("Unknown checker: " + name)
 		}
 	}
-	var results []*base.CheckResult
-	for _, checker := range checkers {
-		r, err := checker.Check(ctx)
-		if err != nil {
-			log.Warnf("Checker(%s): %s", checker.Name(), err)
+	if ctx.KubeCache == nil && ctx.KubeClient != nil {
+		ctx.KubeCache = kubecache.New(ctx.KubeClient)
+	}
+
+	// Exclusive checkers run one by one before the others so that they
+	// observe a quiet system. The rest share a pool of workers.
+	// Results are kept in the order of checkerNames.
+	outputs := make([][]*base.CheckResult, len(checkers))
+	var shared []int
+	for i, checker := range checkers {
+		if checker.Metadata().Exclusive {
+			outputs[i] = runChecker(ctx, checkerNames[i], checker)
+		} else {
+			shared = append(shared, i)
 		}
+	}
+
+	parallelism := ctx.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	taskChan := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range taskChan {
+				outputs[i] = runChecker(ctx, checkerNames[i], checkers[i])
+			}
+		}()
+	}
+	for _, i := range shared {
+		taskChan <- i
+	}
+	close(taskChan)
+	wg.Wait()
+
+	var results []*base.CheckResult
+	for _, r := range outputs {
 		results = append(results, r...)
 	}
+	for _, r := range results {
+		r.Status = r.GetStatus()
+		r.Severity = r.GetSeverity()
+	}
 
 	return results, nil
 }
+
+// runChecker runs a single checker within its time budget. A checker that
+// does not return in time is abandoned and reported as timed out.
+func runChecker(ctx *base.CheckContext, name string, checker Checker) (results []*base.CheckResult) {
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		for _, r := range results {
+			r.Duration = duration
+		}
+	}()
+
+	parent := ctx.Ctx()
+	if parent.Err() != nil {
+		return []*base.CheckResult{cancelledResult(checker, parent.Err())}
+	}
+	if reason := unmetRequirement(ctx, checker.Metadata()); reason != "" {
+		log.Debugf("Skip checker(%s): %s", checker.Name(), reason)
+		return []*base.CheckResult{
+			{
+				Checker:     checker.Name(),
+				Status:      base.StatusSkipped,
+				Description: "Skipped because it " + reason,
+			},
+		}
+	}
+
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	timeout := ctx.TimeoutOf(name)
+	if timeout > 0 {
+		runCtx, cancel = context.WithTimeout(parent, timeout)
+	} else {
+		runCtx, cancel = context.WithCancel(parent)
+	}
+	defer cancel()
+
+	chkCtx := *ctx
+	chkCtx.Context = runCtx
+	chkCtx.Params = ctx.CheckerParams[name]
+	chkCtx.Config = ctx.CheckerConfigs[name]
+
+	done := make(chan checkOutput, 1)
+	go func() {
+		r, err := checker.Check(&chkCtx)
+		done <- checkOutput{results: r, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		if out.err != nil {
+			log.Warnf("Checker(%s): %s", checker.Name(), out.err)
+			return append(out.results, erroredResult(checker, out.err))
+		}
+		return out.results
+	case <-runCtx.Done():
+		if parent.Err() != nil {
+			return []*base.CheckResult{cancelledResult(checker, parent.Err())}
+		}
+		log.Warnf("Checker(%s): timed out after %s", checker.Name(), timeout)
+		return []*base.CheckResult{
+			{
+				Checker:     checker.Name(),
+				Status:      base.StatusErrored,
+				Error:       fmt.Sprintf("Checker timed out after %s", timeout),
+				Description: "The checker did not finish within its time budget and its results are incomplete.",
+				Recommendations: []string{
+					fmt.Sprintf("Increase the time budget with `--checker-timeout %s=<duration>`.", name),
+				},
+			},
+		}
+	}
+}
+
+// unmetRequirement returns why the checker can't run in ctx, or "" if it can.
+func unmetRequirement(ctx *base.CheckContext, meta base.CheckerMetadata) string {
+	for _, flag := range meta.RequiredFlags {
+		if ctx.Environment == nil || !ctx.Environment.HasFlag(flag) {
+			return fmt.Sprintf("requires %s environment", flag)
+		}
+	}
+	if meta.NeedsRoot && (ctx.Environment == nil || !ctx.Environment.HasFlag("root")) {
+		return "requires root"
+	}
+	if meta.NeedsKubeClient && ctx.KubeClient == nil {
+		return "requires a Kubernetes client. Check your kubeconfig or specify --kube-config-path"
+	}
+	if meta.NeedsPod && ctx.Pod.Name == "" {
+		return "requires a pod. Specify one with --pod <namespace>/<name>"
+	}
+	return ""
+}
+
+func erroredResult(checker Checker, err error) *base.CheckResult {
+	return &base.CheckResult{
+		Checker:     checker.Name(),
+		Status:      base.StatusErrored,
+		Error:       fmt.Sprintf("Checker failed to run: %s", err),
+		Description: "The checker could not complete, so the state it checks is unknown.",
+	}
+}
+
+// cancelledResult is errored so that an interrupted run doesn't exit as if all checks passed.
+func cancelledResult(checker Checker, err error) *base.CheckResult {
+	return &base.CheckResult{
+		Checker:     checker.Name(),
+		Status:      base.StatusErrored,
+		Error:       fmt.Sprintf("Checker was cancelled because the run was stopped: %s", err),
+		Description: "The run was interrupted, so the state the checker checks is unknown.",
+	}
+}
@@ -0,0 +1,199 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/env"
+)
+
+type sleepChecker struct {
+	d time.Duration
+}
+
+func (c *sleepChecker) Name() string {
+	return "Sleep"
+}
+
+func (c *sleepChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{}
+}
+
+func (c *sleepChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	select {
+	case <-time.After(c.d):
+	case <-ctx.Ctx().Done():
+		return nil, ctx.Ctx().Err()
+	}
+	return []*base.CheckResult{{Checker: c.Name()}}, nil
+}
+
+func TestRunCheckerInTime(t *testing.T) {
+	ctx := &base.CheckContext{Timeout: time.Second}
+	results := runChecker(ctx, "sleep", &sleepChecker{d: time.Millisecond})
+	if len(results) != 1 || !results[0].Ok() {
+		t.Errorf("Expect one ok result but got %+v", results)
+	}
+}
+
+func TestRunCheckerTimeout(t *testing.T) {
+	ctx := &base.CheckContext{
+		Timeout: time.Minute,
+		CheckerTimeouts: map[string]time.Duration{
+			"sleep": 10 * time.Millisecond,
+		},
+	}
+	results := runChecker(ctx, "sleep", &sleepChecker{d: time.Minute})
+	if len(results) != 1 || results[0].Status != base.StatusErrored {
+		t.Errorf("Expect one timed out result but got %+v", results)
+	}
+}
+
+func TestRunCheckerCancelled(t *testing.T) {
+	runCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx := &base.CheckContext{Context: runCtx}
+	results := runChecker(ctx, "sleep", &sleepChecker{d: time.Minute})
+	if len(results) != 1 || results[0].Status != base.StatusErrored {
+		t.Errorf("Expect one cancelled result as errored but got %+v", results)
+	}
+}
+
+type exclusiveChecker struct {
+	sleepChecker
+}
+
+func (c *exclusiveChecker) Name() string {
+	return "Exclusive"
+}
+
+func (c *exclusiveChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{Exclusive: true}
+}
+
+func (c *exclusiveChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	return []*base.CheckResult{{Checker: c.Name()}}, nil
+}
+
+func TestCheckParallelOrder(t *testing.T) {
+	allCheckers["test-slow"] = &sleepChecker{d: 100 * time.Millisecond}
+	allCheckers["test-exclusive"] = &exclusiveChecker{}
+	defer delete(allCheckers, "test-slow")
+	defer delete(allCheckers, "test-exclusive")
+
+	ctx := &base.CheckContext{Parallelism: 4}
+	start := time.Now()
+	results, err := Check(ctx, []string{"test-slow", "test-exclusive", "test-slow", "test-slow", "test-slow"})
+	if err != nil {
+		t.Fatalf("Expect no error but got: %+v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 400*time.Millisecond {
+		t.Errorf("Expect checkers to run concurrently but took %s", elapsed)
+	}
+	names := []string{}
+	for _, r := range results {
+		names = append(names, r.Checker)
+	}
+	expected := []string{"Sleep", "Exclusive", "Sleep", "Sleep", "Sleep"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expect results %v but got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expect results %v but got %v", expected, names)
+			break
+		}
+	}
+}
+
+type failingChecker struct{}
+
+func (c *failingChecker) Name() string {
+	return "Failing"
+}
+
+func (c *failingChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{}
+}
+
+func (c *failingChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	return []*base.CheckResult{{Checker: c.Name()}}, errors.New("boom")
+}
+
+func TestRunCheckerError(t *testing.T) {
+	results := runChecker(&base.CheckContext{}, "failing", &failingChecker{})
+	if len(results) != 2 {
+		t.Fatalf("Expect partial result and errored result but got %+v", results)
+	}
+	r := results[1]
+	if r.Checker != "Failing" || r.Status != base.StatusErrored || !strings.Contains(r.Error, "boom") {
+		t.Errorf("Expect errored result but got %+v", r)
+	}
+}
+
+type requirementChecker struct {
+	sleepChecker
+	meta base.CheckerMetadata
+}
+
+func (c *requirementChecker) Metadata() base.CheckerMetadata {
+	return c.meta
+}
+
+func TestRunCheckerRequirements(t *testing.T) {
+	ctx := &base.CheckContext{
+		Environment: &env.StaticEnvironment{
+			Flags: []string{"linux"},
+		},
+	}
+	tests := []struct {
+		meta    base.CheckerMetadata
+		skipped bool
+	}{
+		{base.CheckerMetadata{RequiredFlags: []string{"linux"}}, false},
+		{base.CheckerMetadata{RequiredFlags: []string{"windows"}}, true},
+		{base.CheckerMetadata{NeedsRoot: true}, true},
+		{base.CheckerMetadata{NeedsKubeClient: true}, true},
+	}
+	for _, test := range tests {
+		results := runChecker(ctx, "test", &requirementChecker{meta: test.meta})
+		if len(results) != 1 {
+			t.Fatalf("Expect one result but got %+v", results)
+		}
+		skipped := results[0].Status == base.StatusSkipped
+		if skipped != test.skipped {
+			t.Errorf("Expect skipped == %v for %+v but got %+v", test.skipped, test.meta, results[0])
+		}
+		if skipped && results[0].Description == "" {
+			t.Errorf("Expect skip reason for %+v", test.meta)
+		}
+	}
+}
+
+func TestCheckSharesKubeCache(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+	)
+	lists := 0
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists++
+		return false, nil, nil
+	})
+	ctx := &base.CheckContext{KubeClient: client, Parallelism: 2}
+	if _, err := Check(ctx, []string{"kubepod", "podschedule"}); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if lists != 1 {
+		t.Errorf("Expect pods listed once but got %d", lists)
+	}
+}
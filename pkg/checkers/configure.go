@@ -0,0 +1,39 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Azure/kdebug/pkg/config"
+)
+
+// ConfigurableChecker reads its settings from its section of the config file.
+// The section is passed to the checker in CheckContext.Config when it runs.
+type ConfigurableChecker interface {
+	ValidateConfig(*config.Section) error
+}
+
+// ValidateConfig checks that every section of cfg belongs to a configurable
+// checker and is valid for it.
+func ValidateConfig(cfg *config.Config) error {
+	names := make([]string, 0, len(cfg.Checkers))
+	for name := range cfg.Checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		checker, ok := allCheckers[name]
+		if !ok {
+			return fmt.Errorf("Unknown checker in config: %s", name)
+		}
+		c, ok := checker.(ConfigurableChecker)
+		if !ok {
+			return fmt.Errorf("Checker %s has no settings", name)
+		}
+		if err := c.ValidateConfig(cfg.Checkers[name]); err != nil {
+			return fmt.Errorf("Invalid settings of checker %s: %s", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/Azure/kdebug/pkg/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	for _, data := range []string{
+		"checkers:\n  nosuchchecker:\n    foo: 1\n",
+		"checkers:\n  dummy:\n    foo: 1\n",
+		"checkers:\n  diskusage:\n    nosuchkey: 1\n",
+	} {
+		cfg, err := config.Parse([]byte(data))
+		if err != nil {
+			t.Fatalf("Fail to parse config: %s", err)
+		}
+		if err := ValidateConfig(cfg); err == nil {
+			t.Errorf("Expect error for config %q", data)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package checker
+
+import (
+	"bytes"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Azure/kdebug/pkg/checkers/declarative"
+)
+
+// LoadDeclarativeChecks registers checkers parsed from data, then checkers
+// loaded from files or dirs in paths. Existing checkers take precedence.
+func LoadDeclarativeChecks(data []byte, paths []string) error {
+	checkers, err := declarative.Parse(data)
+	if err != nil {
+		return fmt.Errorf("Fail to parse declarative checks: %s", err)
+	}
+	loaded, err := declarative.Load(paths)
+	if err != nil {
+		return err
+	}
+	for _, c := range append(checkers, loaded...) {
+		if err := Register(c.Name(), c); err != nil {
+			log.Warnf("Ignore declarative checker %s: %s", c.Name(), err)
+		}
+	}
+	return nil
+}
+
+// DeclarativeData returns definitions of declarative checkers among names,
+// which need to be forwarded to remote machines in batch mode.
+func DeclarativeData(names []string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, name := range names {
+		c, ok := allCheckers[name].(*declarative.DeclarativeChecker)
+		if !ok {
+			continue
+		}
+		data, err := yaml.Marshal(c.Definition())
+		if err != nil {
+			return nil, fmt.Errorf("Fail to marshal declarative checker %s: %s", name, err)
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
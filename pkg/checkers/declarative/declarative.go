@@ -0,0 +1,419 @@
+package declarative
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/checkers/dns"
+	"github.com/Azure/kdebug/pkg/checkers/http"
+	"github.com/Azure/kdebug/pkg/checkers/tcpping"
+)
+
+const Tag = "declarative"
+
+var (
+	// ProcSysPath is where sysctl values are read from.
+	ProcSysPath = "/proc/sys"
+)
+
+// Definition is a checker defined in YAML. A file may contain multiple
+// definitions separated by ---.
+type Definition struct {
+	Name          string   `yaml:"name"`
+	Description   string   `yaml:"description"`
+	Tags          []string `yaml:"tags,omitempty"`
+	RequiredFlags []string `yaml:"requiredFlags,omitempty"`
+	NeedsRoot     bool     `yaml:"needsRoot,omitempty"`
+	Checks        []Check  `yaml:"checks"`
+}
+
+// Check is a single assertion. Exactly one of the probes must be set.
+type Check struct {
+	Name            string        `yaml:"name"`
+	Severity        base.Severity `yaml:"severity,omitempty"`
+	Recommendations []string      `yaml:"recommendations,omitempty"`
+	HelpLinks       []string      `yaml:"helpLinks,omitempty"`
+
+	File    *FileCheck       `yaml:"file,omitempty"`
+	Sysctl  *SysctlCheck     `yaml:"sysctl,omitempty"`
+	TCP     *TCPCheck        `yaml:"tcp,omitempty"`
+	Command *CommandCheck    `yaml:"command,omitempty"`
+	HTTP    *http.HttpTarget `yaml:"http,omitempty"`
+	DNS     *DNSCheck        `yaml:"dns,omitempty"`
+}
+
+type FileCheck struct {
+	Path string `yaml:"path"`
+	// Absent asserts the file does not exist.
+	Absent bool `yaml:"absent,omitempty"`
+	// Matches is a regex the file content must match.
+	Matches string `yaml:"matches,omitempty"`
+}
+
+type SysctlCheck struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value"`
+}
+
+type TCPCheck struct {
+	Address string `yaml:"address"`
+}
+
+type CommandCheck struct {
+	// Run is executed with sh -c.
+	Run string `yaml:"run"`
+	// Matches is a regex the output must match.
+	Matches string `yaml:"matches,omitempty"`
+}
+
+type DNSCheck struct {
+	Server string `yaml:"server"`
+	Query  string `yaml:"query"`
+}
+
+type DeclarativeChecker struct {
+	def Definition
+	// patterns are compiled matches of checks by index, nil if not set.
+	patterns []*regexp.Regexp
+	http     *http.HttpChecker
+	tcp      *tcpping.TCPChecker
+	dns      *dns.DnsChecker
+}
+
+// DefaultDirs returns existing dirs to load declarative checks from.
+func DefaultDirs() []string {
+	candidates := []string{}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".kdebug", "checks"))
+	}
+	candidates = append(candidates, "/etc/kdebug/checks.d")
+
+	dirs := []string{}
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// Load loads checkers from YAML files, or from *.yaml and *.yml files in dirs.
+func Load(paths []string) ([]*DeclarativeChecker, error) {
+	checkers := []*DeclarativeChecker{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("Fail to load declarative checks: %s", err)
+		}
+		files := []string{path}
+		if info.IsDir() {
+			yamls, _ := filepath.Glob(filepath.Join(path, "*.yaml"))
+			ymls, _ := filepath.Glob(filepath.Join(path, "*.yml"))
+			files = append(yamls, ymls...)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("Fail to read declarative checks file %s: %s", file, err)
+			}
+			cs, err := Parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("Fail to parse declarative checks file %s: %s", file, err)
+			}
+			checkers = append(checkers, cs...)
+		}
+	}
+	return checkers, nil
+}
+
+// Parse parses checker definitions from YAML.
+func Parse(data []byte) ([]*DeclarativeChecker, error) {
+	checkers := []*DeclarativeChecker{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	for {
+		var def Definition
+		err := decoder.Decode(&def)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		checker, err := New(def)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
+
+// New validates the definition and compiles its patterns.
+func New(def Definition) (*DeclarativeChecker, error) {
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+	patterns := make([]*regexp.Regexp, len(def.Checks))
+	for i, check := range def.Checks {
+		expr := ""
+		if check.File != nil {
+			expr = check.File.Matches
+		} else if check.Command != nil {
+			expr = check.Command.Matches
+		}
+		if expr == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("check %d of checker %s: invalid regex %q: %s", i, def.Name, expr, err)
+		}
+		patterns[i] = pattern
+	}
+	return &DeclarativeChecker{
+		def:      def,
+		patterns: patterns,
+		http:     http.New(),
+		tcp:      tcpping.New(),
+		dns:      dns.New(),
+	}, nil
+}
+
+func (d *Definition) validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(d.Checks) == 0 {
+		return fmt.Errorf("checker %s has no checks", d.Name)
+	}
+	for i, c := range d.Checks {
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("check %d of checker %s: %s", i, d.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c *Check) validate() error {
+	probes := 0
+	for _, set := range []bool{c.File != nil, c.Sysctl != nil, c.TCP != nil,
+		c.Command != nil, c.HTTP != nil, c.DNS != nil} {
+		if set {
+			probes++
+		}
+	}
+	if probes != 1 {
+		return fmt.Errorf("exactly one of file, sysctl, tcp, command, http and dns is required")
+	}
+
+	switch {
+	case c.File != nil:
+		if c.File.Path == "" {
+			return fmt.Errorf("file path is required")
+		}
+	case c.Sysctl != nil:
+		if c.Sysctl.Key == "" {
+			return fmt.Errorf("sysctl key is required")
+		}
+		// Keys are joined to ProcSysPath, so they must not escape it
+		if strings.Contains(c.Sysctl.Key, "/") || strings.Contains(c.Sysctl.Key, "..") {
+			return fmt.Errorf("invalid sysctl key %q", c.Sysctl.Key)
+		}
+	case c.TCP != nil:
+		if _, _, err := net.SplitHostPort(c.TCP.Address); err != nil {
+			return fmt.Errorf("invalid tcp address %q: %s", c.TCP.Address, err)
+		}
+	case c.Command != nil:
+		if c.Command.Run == "" {
+			return fmt.Errorf("command to run is required")
+		}
+	case c.HTTP != nil:
+		if c.HTTP.URL == "" {
+			return fmt.Errorf("http url is required")
+		}
+	case c.DNS != nil:
+		if c.DNS.Server == "" || c.DNS.Query == "" {
+			return fmt.Errorf("dns server and query are required")
+		}
+	}
+	return nil
+}
+
+// Definition returns the definition the checker is compiled from.
+func (c *DeclarativeChecker) Definition() Definition {
+	return c.def
+}
+
+func (c *DeclarativeChecker) Name() string {
+	return c.def.Name
+}
+
+func (c *DeclarativeChecker) Metadata() base.CheckerMetadata {
+	description := c.def.Description
+	if description == "" {
+		description = fmt.Sprintf("Declarative checker with %d checks.", len(c.def.Checks))
+	}
+	return base.CheckerMetadata{
+		Description:   description,
+		RequiredFlags: c.def.RequiredFlags,
+		NeedsRoot:     c.def.NeedsRoot,
+		Tags:          append([]string{Tag}, c.def.Tags...),
+	}
+}
+
+func (c *DeclarativeChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	results := []*base.CheckResult{}
+	for i, check := range c.def.Checks {
+		result, err := c.runCheck(ctx.Ctx(), check, c.patterns[i])
+		if err != nil {
+			return results, err
+		}
+		result.Checker = c.Name()
+		if !result.Ok() {
+			if check.Severity != "" {
+				result.Severity = check.Severity
+			}
+			if len(check.Recommendations) > 0 {
+				result.Recommendations = check.Recommendations
+			}
+			if len(check.HelpLinks) > 0 {
+				result.HelpLinks = check.HelpLinks
+			}
+		}
+		if check.Name != "" {
+			result.Description = fmt.Sprintf("[%s] %s", check.Name, result.Description)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (c *DeclarativeChecker) runCheck(ctx context.Context, check Check, pattern *regexp.Regexp) (*base.CheckResult, error) {
+	switch {
+	case check.File != nil:
+		return checkFile(check.File, pattern), nil
+	case check.Sysctl != nil:
+		return checkSysctl(check.Sysctl), nil
+	case check.TCP != nil:
+		if err := c.tcp.Ping(ctx, check.TCP.Address); err != nil {
+			return &base.CheckResult{
+				Error:       err.Error(),
+				Description: fmt.Sprintf("Fail to establish tcp connection to %s", check.TCP.Address),
+			}, nil
+		}
+		return &base.CheckResult{
+			Description: fmt.Sprintf("Successfully establish tcp connection to %s", check.TCP.Address),
+		}, nil
+	case check.Command != nil:
+		return checkCommand(ctx, check.Command, pattern), nil
+	case check.HTTP != nil:
+		return c.http.CheckTarget(ctx, *check.HTTP)
+	case check.DNS != nil:
+		server := dns.DnsServer{
+			Name:   check.DNS.Server,
+			Server: check.DNS.Server,
+		}
+		return c.dns.CheckServer(ctx, server, check.DNS.Query)
+	}
+	return nil, fmt.Errorf("No probe in check %s", check.Name)
+}
+
+func checkFile(f *FileCheck, pattern *regexp.Regexp) *base.CheckResult {
+	if f.Absent {
+		_, err := os.Stat(f.Path)
+		if err == nil {
+			return &base.CheckResult{
+				Error:       fmt.Sprintf("File %s exists", f.Path),
+				Description: fmt.Sprintf("Expect file %s to be absent", f.Path),
+			}
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fileErrorResult(f, err)
+		}
+		return &base.CheckResult{
+			Description: fmt.Sprintf("File %s is absent", f.Path),
+		}
+	}
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &base.CheckResult{
+			Error:       err.Error(),
+			Description: fmt.Sprintf("Fail to read file %s", f.Path),
+		}
+	}
+	if err != nil {
+		return fileErrorResult(f, err)
+	}
+	if pattern != nil && !pattern.Match(data) {
+		return &base.CheckResult{
+			Error:       fmt.Sprintf("Content of file %s does not match %q", f.Path, f.Matches),
+			Description: fmt.Sprintf("Unexpected content of file %s", f.Path),
+		}
+	}
+	return &base.CheckResult{
+		Description: fmt.Sprintf("File %s is as expected", f.Path),
+	}
+}
+
+// fileErrorResult reports that the file can't be checked, e.g. permission denied.
+func fileErrorResult(f *FileCheck, err error) *base.CheckResult {
+	return &base.CheckResult{
+		Status:      base.StatusErrored,
+		Error:       err.Error(),
+		Description: fmt.Sprintf("Fail to check file %s", f.Path),
+	}
+}
+
+func checkSysctl(s *SysctlCheck) *base.CheckResult {
+	path := filepath.Join(ProcSysPath, strings.ReplaceAll(s.Key, ".", "/"))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &base.CheckResult{
+			Error:       err.Error(),
+			Description: fmt.Sprintf("Fail to read sysctl %s", s.Key),
+		}
+	}
+	value := strings.Join(strings.Fields(string(data)), " ")
+	if value != s.Value {
+		return &base.CheckResult{
+			Error:       fmt.Sprintf("sysctl %s is %q but expect %q", s.Key, value, s.Value),
+			Description: fmt.Sprintf("Unexpected value of sysctl %s", s.Key),
+		}
+	}
+	return &base.CheckResult{
+		Description: fmt.Sprintf("sysctl %s is %q", s.Key, value),
+	}
+}
+
+func checkCommand(ctx context.Context, c *CommandCheck, pattern *regexp.Regexp) *base.CheckResult {
+	output, err := exec.CommandContext(ctx, "sh", "-c", c.Run).CombinedOutput()
+	if err != nil {
+		return &base.CheckResult{
+			Error:       fmt.Sprintf("Command %q failed: %s", c.Run, err),
+			Description: fmt.Sprintf("Output: %s", strings.TrimSpace(string(output))),
+		}
+	}
+	if pattern != nil && !pattern.Match(output) {
+		return &base.CheckResult{
+			Error:       fmt.Sprintf("Output of command %q does not match %q", c.Run, c.Matches),
+			Description: fmt.Sprintf("Output: %s", strings.TrimSpace(string(output))),
+		}
+	}
+	return &base.CheckResult{
+		Description: fmt.Sprintf("Command %q succeeded", c.Run),
+	}
+}
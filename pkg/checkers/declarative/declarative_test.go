@@ -0,0 +1,169 @@
+package declarative
+
+import (
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+)
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "net", "ipv4"), 0755)
+	os.WriteFile(filepath.Join(dir, "net", "ipv4", "ip_forward"), []byte("0\n"), 0644)
+	ProcSysPath = dir
+	defer func() { ProcSysPath = "/proc/sys" }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Fail to listen: %s", err)
+	}
+	defer listener.Close()
+
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.WriteHeader(nethttp.StatusNotFound)
+	}))
+	defer server.Close()
+
+	data := `
+name: site
+checks:
+  - name: forwarding
+    sysctl: {key: net.ipv4.ip_forward, value: "1"}
+    severity: critical
+    recommendations: [Enable IP forwarding.]
+    helpLinks: [https://example.com]
+  - file: {path: ` + filepath.Join(dir, "net", "ipv4", "ip_forward") + `, matches: "^0"}
+  - file: {path: ` + filepath.Join(dir, "nosuchfile") + `, absent: true}
+  - tcp: {address: "` + listener.Addr().String() + `"}
+  - command: {run: echo hello, matches: hel+o}
+  - command: {run: exit 1}
+  - http: {url: "` + server.URL + `", expect: 200}
+`
+	checkers, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(checkers) != 1 || checkers[0].Name() != "site" {
+		t.Fatalf("Expect checker site but got %+v", checkers)
+	}
+
+	results, err := checkers[0].Check(&base.CheckContext{})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	expectedOk := []bool{false, true, true, true, true, false, false}
+	if len(results) != len(expectedOk) {
+		t.Fatalf("Expect %d results but got %d", len(expectedOk), len(results))
+	}
+	for i, r := range results {
+		if r.Ok() != expectedOk[i] {
+			t.Errorf("Expect result %d ok=%v but got %+v", i, expectedOk[i], r)
+		}
+		if r.Checker != "site" {
+			t.Errorf("Unexpected checker name %s", r.Checker)
+		}
+	}
+	r := results[0]
+	if r.Severity != base.SeverityCritical || r.Recommendations[0] != "Enable IP forwarding." ||
+		r.HelpLinks[0] != "https://example.com" || !strings.HasPrefix(r.Description, "[forwarding]") {
+		t.Errorf("Unexpected result of sysctl check: %+v", r)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, data := range []string{
+		"checks:\n  - tcp: {address: a:1}\n",
+		"name: a\n",
+		"name: a\nchecks:\n  - {}\n",
+		"name: a\nchecks:\n  - tcp: {address: a}\n",
+		"name: a\nchecks:\n  - tcp: {address: a:1}\n    file: {path: /a}\n",
+		"name: a\nchecks:\n  - command: {run: ls, matches: '('}\n",
+		"name: a\nchecks:\n  - tcp: {address: a:1, foo: bar}\n",
+		"name: a\nchecks:\n  - sysctl: {key: ../../etc/shadow, value: a}\n",
+		"name: a\nchecks:\n  - sysctl: {key: net/ipv4/ip_forward, value: a}\n",
+		"name: a\nchecks:\n  - sysctl: {key: net..ipv4, value: a}\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Expect error for %q", data)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.yaml"), []byte(`
+name: a
+checks:
+  - file: {path: /}
+---
+name: b
+checks:
+  - file: {path: /}
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "c.yml"), []byte("name: c\nchecks:\n  - file: {path: /}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("not a check"), 0644)
+
+	checkers, err := Load([]string{dir})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(checkers) != 3 {
+		t.Errorf("Expect 3 checkers but got %d", len(checkers))
+	}
+	if _, err := Load([]string{filepath.Join(dir, "nosuchfile")}); err == nil {
+		t.Errorf("Expect error for missing file")
+	}
+}
+
+func TestCheckFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, []byte("a"), 0644)
+
+	// Not a directory is neither absent nor present
+	r := checkFile(&FileCheck{Path: filepath.Join(file, "sub"), Absent: true}, nil)
+	if r.GetStatus() != base.StatusErrored {
+		t.Errorf("Expect errored result but got %+v", r)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("Root can access any file")
+	}
+	forbidden := filepath.Join(dir, "forbidden")
+	os.Mkdir(forbidden, 0755)
+	os.WriteFile(filepath.Join(forbidden, "secret"), []byte("a"), 0644)
+	os.Chmod(forbidden, 0)
+	defer os.Chmod(forbidden, 0755)
+	for _, check := range []*FileCheck{
+		{Path: filepath.Join(forbidden, "secret"), Absent: true},
+		{Path: filepath.Join(forbidden, "secret")},
+	} {
+		if r := checkFile(check, nil); r.GetStatus() != base.StatusErrored {
+			t.Errorf("Expect errored result of permission denied but got %+v", r)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Definition{Name: "a", Checks: []Check{{Command: &CommandCheck{Run: "ls", Matches: "("}}}})
+	if err == nil {
+		t.Errorf("Expect error of invalid regex")
+	}
+	if _, err := New(Definition{Name: "a"}); err == nil {
+		t.Errorf("Expect error of no checks")
+	}
+	c, err := New(Definition{Name: "a", Checks: []Check{{Command: &CommandCheck{Run: "echo hello", Matches: "^hel+o"}}}})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	results, err := c.Check(&base.CheckContext{})
+	if err != nil || len(results) != 1 || !results[0].Ok() {
+		t.Errorf("Expect passed result but got %+v, %v", results, err)
+	}
+}
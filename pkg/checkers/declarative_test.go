@@ -0,0 +1,43 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/kdebug/pkg/checkers/declarative"
+)
+
+func TestLoadDeclarativeChecks(t *testing.T) {
+	data := []byte(`
+name: sitefile
+checks:
+  - file: {path: /etc/hosts}
+---
+name: dns
+checks:
+  - file: {path: /etc/hosts}
+`)
+	if err := LoadDeclarativeChecks(data, nil); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	defer delete(allCheckers, "sitefile")
+
+	if _, ok := allCheckers["sitefile"].(*declarative.DeclarativeChecker); !ok {
+		t.Errorf("Expect declarative checker sitefile to be registered")
+	}
+	if _, ok := allCheckers["dns"].(*declarative.DeclarativeChecker); ok {
+		t.Errorf("Expect built-in checker dns to take precedence")
+	}
+
+	forwarded, err := DeclarativeData([]string{"dns", "sitefile"})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	checkers, err := declarative.Parse(forwarded)
+	if err != nil || len(checkers) != 1 || checkers[0].Name() != "sitefile" {
+		t.Errorf("Expect forwarded sitefile checker but got %v, %v", checkers, err)
+	}
+	if strings.Contains(string(forwarded), "name: dns") {
+		t.Errorf("Expect built-in checkers not forwarded: %s", forwarded)
+	}
+}
@@ -33,13 +33,15 @@ func (c *DiskReadOnlyChecker) Name() string {
 	return "DiskReadOnly"
 }
 
-func (c *DiskReadOnlyChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
-	if !ctx.Environment.HasFlag("linux") {
-		// This checker is only valid on Linux.
-		log.Debugf("Skip %s checker in non-linux os", c.Name())
-		return []*base.CheckResult{}, nil
+func (c *DiskReadOnlyChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:   "Check if the user home directory is read-only.",
+		RequiredFlags: []string{"linux"},
+		Tags:          []string{"disk", "node"},
 	}
+}
 
+func (c *DiskReadOnlyChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("Fail to get user home dir. %w", err)
@@ -59,6 +61,7 @@ func (c *DiskReadOnlyChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult
 			}
 			result = &base.CheckResult{
 				Checker:         c.Name(),
+				Severity:        base.SeverityCritical,
 				Error:           "Disk might be read-only",
 				Description:     fmt.Sprintf("Cannot create a temp file in %s due to %s", homeDir, err),
 				Recommendations: []string{recommendation},
@@ -1,6 +1,7 @@
 package diskusage
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -10,6 +11,7 @@ import (
 	"os/exec"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/config"
 )
 
 const (
@@ -59,21 +61,66 @@ type DfRow struct {
 	MountedOn  string
 }
 
+// Config is the diskusage section of the config file.
+type Config struct {
+	Threshold    int      `yaml:"threshold"`
+	BigFilePaths []string `yaml:"bigFilePaths"`
+	BigFileCount int      `yaml:"bigFileCount"`
+}
+
 type DiskUsageChecker struct {
+	config Config
 }
 
 func New() *DiskUsageChecker {
-	return &DiskUsageChecker{}
+	return &DiskUsageChecker{
+		config: Config{
+			Threshold:    DiskUsageRateThreshold,
+			BigFilePaths: InterestedBigFilePath,
+			BigFileCount: InterestedBigFileNum,
+		},
+	}
+}
+
+// loadConfig returns the default settings overridden by section.
+func (c *DiskUsageChecker) loadConfig(section *config.Section) (Config, error) {
+	cfg := c.config
+	if section == nil {
+		return cfg, nil
+	}
+	if err := section.Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Threshold <= 0 || cfg.Threshold > 100 {
+		return cfg, fmt.Errorf("threshold must be between 1 and 100: %d", cfg.Threshold)
+	}
+	return cfg, nil
+}
+
+func (c *DiskUsageChecker) ValidateConfig(section *config.Section) error {
+	_, err := c.loadConfig(section)
+	return err
 }
 
 func (c *DiskUsageChecker) Name() string {
 	return "DiskUsage"
 }
 
+func (c *DiskUsageChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description: "Check usage of the root filesystem and list top large files.",
+		Tags:        []string{"disk", "node"},
+	}
+}
+
 func (c *DiskUsageChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	cfg, err := c.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
 	result := []*base.CheckResult{}
 
-	rst, err := c.getDiskUsage()
+	rst, err := c.getDiskUsage(ctx.Ctx(), cfg)
 	if err != nil {
 		return result, err
 	}
@@ -82,8 +129,8 @@ func (c *DiskUsageChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, e
 	return result, nil
 }
 
-func (c *DiskUsageChecker) getDiskUsage() (*base.CheckResult, error) {
-	out, err := exec.Command("uname").Output()
+func (c *DiskUsageChecker) getDiskUsage(ctx context.Context, cfg Config) (*base.CheckResult, error) {
+	out, err := exec.CommandContext(ctx, "uname").Output()
 	if err != nil {
 		return &base.CheckResult{
 			Checker:     c.Name(),
@@ -101,7 +148,7 @@ func (c *DiskUsageChecker) getDiskUsage() (*base.CheckResult, error) {
 		}, nil
 	}
 
-	out, err = exec.Command("df", "-h").Output()
+	out, err = exec.CommandContext(ctx, "df", "-h").Output()
 	if err != nil {
 		return &base.CheckResult{
 			Checker:     c.Name(),
@@ -120,11 +167,11 @@ func (c *DiskUsageChecker) getDiskUsage() (*base.CheckResult, error) {
 	}
 
 	found, row := getUsageAt("/", rows)
-	if found && row.Use > DiskUsageRateThreshold {
+	if found && row.Use > cfg.Threshold {
 		bigFiles := []string{}
 
-		for _, path := range InterestedBigFilePath {
-			output, err := FindTopSizeFiles(path, InterestedBigFileNum)
+		for _, path := range cfg.BigFilePaths {
+			output, err := FindTopSizeFiles(ctx, path, cfg.BigFileCount)
 			if err != nil {
 				return &base.CheckResult{
 					Checker:         c.Name(),
@@ -147,7 +194,7 @@ func (c *DiskUsageChecker) getDiskUsage() (*base.CheckResult, error) {
 
 	return &base.CheckResult{
 		Checker:     c.Name(),
-		Description: fmt.Sprintf("%s Current %v%%, Threshold %v%%", NoHighDiskUsageResult, row.Use, DiskUsageRateThreshold),
+		Description: fmt.Sprintf("%s Current %v%%, Threshold %v%%", NoHighDiskUsageResult, row.Use, cfg.Threshold),
 	}, nil
 }
 
@@ -214,9 +261,9 @@ func FormatHighDfDescription(row DfRow) string {
 	return fmt.Sprintf("[Used %d%%] Filesystem: %s, UsedSize: %s, AvailableSize: %s, MountedOn %s", row.Use, row.Filesystem, row.Used, row.Avail, row.MountedOn)
 }
 
-func FindTopSizeFiles(path string, topCount int) (string, error) {
+func FindTopSizeFiles(ctx context.Context, path string, topCount int) (string, error) {
 	commandline := fmt.Sprintf("du -ah %s | sort -rh | head -n %d", path, topCount)
-	out, err := exec.Command("bash", "-c", commandline).Output()
+	out, err := exec.CommandContext(ctx, "bash", "-c", commandline).Output()
 
 	if err != nil {
 		return "", err
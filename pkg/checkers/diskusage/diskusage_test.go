@@ -2,6 +2,8 @@ package diskusage
 
 import (
 	"testing"
+
+	"github.com/Azure/kdebug/pkg/config"
 )
 
 func TestDfParse_Success(t *testing.T) {
@@ -81,3 +83,38 @@ func TestDfParse_Failed(t *testing.T) {
 		t.Errorf("Expect error in parseDfResult but not")
 	}
 }
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := config.Parse([]byte(`
+checkers:
+  diskusage:
+    threshold: 80
+`))
+	if err != nil {
+		t.Fatalf("Fail to parse config: %s", err)
+	}
+
+	c := New()
+	loaded, err := c.loadConfig(cfg.Checkers["diskusage"])
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if loaded.Threshold != 80 {
+		t.Errorf("Expect threshold 80 but got %d", loaded.Threshold)
+	}
+	if loaded.BigFileCount != InterestedBigFileNum {
+		t.Errorf("Expect default big file count %d but got %d", InterestedBigFileNum, loaded.BigFileCount)
+	}
+	if c.config.Threshold != DiskUsageRateThreshold {
+		t.Errorf("Expect default threshold unchanged but got %d", c.config.Threshold)
+	}
+
+	cfg, _ = config.Parse([]byte(`
+checkers:
+  diskusage:
+    threshold: 120
+`))
+	if err := c.ValidateConfig(cfg.Checkers["diskusage"]); err == nil {
+		t.Errorf("Expect error for threshold out of range")
+	}
+}
@@ -1,10 +1,14 @@
 package dns
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/config"
 	"github.com/Azure/kdebug/pkg/env"
 	"github.com/miekg/dns"
 )
@@ -12,6 +16,8 @@ import (
 const (
 	PublicDnsRecommendation = "Check your public network connectivity and outbound security settings."
 	CoreDnsRecommendation   = "CoreDNS pods might be down. Check their liveness using `kubectl get pods -n kube-system -o wide -l k8s-app=kube-dns`."
+
+	ResolvConfPath = "/etc/resolv.conf"
 )
 
 var (
@@ -74,6 +80,20 @@ var (
 			"https://coredns.io/plugins/kubernetes/",
 		},
 	}
+	// ClusterDnsServer is the cluster DNS of other Kubernetes distributions.
+	// Its server is the configured or detected cluster DNS.
+	ClusterDnsServer = DnsServer{
+		Name: "Cluster DNS",
+		Queries: []string{
+			"kubernetes.default.svc.cluster.local",
+		},
+		Recommendations: []string{
+			"DNS pods of the cluster might be down. Check their liveness using `kubectl get pods -n kube-system -o wide -l k8s-app=kube-dns`.",
+		},
+		HelpLinks: []string{
+			"https://kubernetes.io/docs/tasks/administer-cluster/dns-debugging-resolution/",
+		},
+	}
 	SystemdResolvedDnsServer = DnsServer{
 		Name:   "systemd-resolved",
 		Server: "127.0.0.53",
@@ -91,19 +111,28 @@ var (
 )
 
 type DnsServer struct {
-	Name            string
-	Server          string
-	Queries         []string
-	Recommendations []string
-	HelpLinks       []string
+	Name            string   `yaml:"name"`
+	Server          string   `yaml:"server"`
+	Queries         []string `yaml:"queries"`
+	Recommendations []string `yaml:"recommendations"`
+	HelpLinks       []string `yaml:"helpLinks"`
+}
+
+// Config is the dns section of the config file.
+type Config struct {
+	// Servers replaces the default servers when set.
+	Servers []DnsServer `yaml:"servers"`
+	// ClusterDnsServer replaces the default cluster DNS service IP 10.0.0.10.
+	ClusterDnsServer string `yaml:"clusterDnsServer"`
 }
 
 type DnsClient interface {
-	Exchange(m *dns.Msg, a string) (r *dns.Msg, rtt time.Duration, err error)
+	ExchangeContext(ctx context.Context, m *dns.Msg, a string) (r *dns.Msg, rtt time.Duration, err error)
 }
 
 type DnsChecker struct {
 	client DnsClient
+	config Config
 }
 
 func New() *DnsChecker {
@@ -114,16 +143,60 @@ func New() *DnsChecker {
 	}
 }
 
+// loadConfig returns the default settings overridden by section.
+func (c *DnsChecker) loadConfig(section *config.Section) (Config, error) {
+	cfg := c.config
+	if section == nil {
+		return cfg, nil
+	}
+	if err := section.Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	for _, server := range cfg.Servers {
+		if server.Server == "" || len(server.Queries) == 0 {
+			return cfg, fmt.Errorf("server and queries are required: %+v", server)
+		}
+	}
+	return cfg, nil
+}
+
+func (c *DnsChecker) ValidateConfig(section *config.Section) error {
+	_, err := c.loadConfig(section)
+	return err
+}
+
 func (c *DnsChecker) Name() string {
 	return "Dns"
 }
 
+func (c *DnsChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description: "Check name resolution with public, Azure, cluster and local DNS servers.",
+		Tags:        []string{"network", "dns", "azure"},
+		Parameters: map[string]string{
+			"server": "DNS server to query, optionally with a port. Defaults to the first nameserver in " + ResolvConfPath,
+			"query":  "Domain name to query. Defaults to well known domain names",
+		},
+	}
+}
+
 func (c *DnsChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	cfg, err := c.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
 	result := []*base.CheckResult{}
-	targets := getCheckTargets(ctx.Environment)
+	targets := c.getCheckTargets(ctx.Environment, cfg)
+	if len(ctx.Params) > 0 {
+		target, err := getParamTarget(ctx.Params)
+		if err != nil {
+			return nil, err
+		}
+		targets = []DnsServer{target}
+	}
 	for _, server := range targets {
 		for _, query := range server.Queries {
-			r, err := c.checkServer(server, query)
+			r, err := c.CheckServer(ctx.Ctx(), server, query)
 			if err != nil {
 				return result, err
 			}
@@ -133,6 +206,68 @@ func (c *DnsChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error)
 	return result, nil
 }
 
+func (c *DnsChecker) getCheckTargets(e env.Environment, cfg Config) []DnsServer {
+	if len(cfg.Servers) > 0 {
+		return cfg.Servers
+	}
+
+	targets := getCheckTargets(e)
+	// Prefer the configured cluster DNS, then the one kubelet uses
+	clusterDns := cfg.ClusterDnsServer
+	if clusterDns == "" {
+		clusterDns = e.GetFacts().ClusterDNS
+	}
+	if clusterDns == "" {
+		return targets
+	}
+	found := false
+	for i := range targets {
+		if targets[i].Name == AksCoreDnsServerInCluster.Name {
+			targets[i].Server = clusterDns
+			found = true
+		}
+	}
+	// AKS targets are only checked on Azure
+	if !found {
+		target := ClusterDnsServer
+		target.Server = clusterDns
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// serverAddress returns the server with port 53 unless it has a port.
+func serverAddress(server string) string {
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+}
+
+// getParamTarget builds an ad-hoc target from command line parameters.
+func getParamTarget(params map[string]string) (DnsServer, error) {
+	target := DnsServer{
+		Name:    "Specified DNS",
+		Server:  params["server"],
+		Queries: GoogleDnsServer.Queries,
+	}
+	if query := params["query"]; query != "" {
+		target.Queries = []string{query}
+	}
+	if target.Server == "" {
+		cfg, err := dns.ClientConfigFromFile(ResolvConfPath)
+		if err != nil {
+			return target, fmt.Errorf("Fail to read nameservers from %s: %s", ResolvConfPath, err)
+		}
+		if len(cfg.Servers) == 0 {
+			return target, fmt.Errorf("No nameserver found in %s", ResolvConfPath)
+		}
+		target.Name = "Local DNS"
+		target.Server = cfg.Servers[0]
+	}
+	return target, nil
+}
+
 func getCheckTargets(e env.Environment) []DnsServer {
 	targets := []DnsServer{
 		GoogleDnsServer,
@@ -152,11 +287,12 @@ func getCheckTargets(e env.Environment) []DnsServer {
 	return targets
 }
 
-func (c *DnsChecker) checkServer(server DnsServer, query string) (*base.CheckResult, error) {
+// CheckServer queries the domain name from the server.
+func (c *DnsChecker) CheckServer(ctx context.Context, server DnsServer, query string) (*base.CheckResult, error) {
 	m := new(dns.Msg)
 	m.SetQuestion(query+".", dns.TypeA)
 	m.RecursionDesired = true
-	r, _, err := c.client.Exchange(m, server.Server+":53")
+	r, _, err := c.client.ExchangeContext(ctx, m, serverAddress(server.Server))
 	if err != nil {
 		return &base.CheckResult{
 			Checker: c.Name(),
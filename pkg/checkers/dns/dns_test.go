@@ -1,6 +1,7 @@
 package dns
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -18,7 +19,7 @@ type FakeDnsClient struct {
 	a string
 }
 
-func (c *FakeDnsClient) Exchange(m *dns.Msg, a string) (r *dns.Msg, rtt time.Duration, err error) {
+func (c *FakeDnsClient) ExchangeContext(ctx context.Context, m *dns.Msg, a string) (r *dns.Msg, rtt time.Duration, err error) {
 	c.m = m
 	c.a = a
 	return c.r, time.Duration(0), c.e
@@ -35,7 +36,7 @@ func TestCheckServer(t *testing.T) {
 	checker := &DnsChecker{
 		client: client,
 	}
-	r, err := checker.checkServer(GoogleDnsServer, "www.bing.com")
+	r, err := checker.CheckServer(context.Background(), GoogleDnsServer, "www.bing.com")
 	if err != nil {
 		t.Errorf("expect no error but got: %+v", err)
 	}
@@ -61,7 +62,7 @@ func TestCheckServerBadRcode(t *testing.T) {
 	checker := &DnsChecker{
 		client: client,
 	}
-	r, err := checker.checkServer(GoogleDnsServer, "www.bing.com")
+	r, err := checker.CheckServer(context.Background(), GoogleDnsServer, "www.bing.com")
 	if err != nil {
 		t.Errorf("expect no error but got: %+v", err)
 	}
@@ -88,7 +89,7 @@ func TestCheckServerError(t *testing.T) {
 	checker := &DnsChecker{
 		client: client,
 	}
-	r, err := checker.checkServer(GoogleDnsServer, "www.bing.com")
+	r, err := checker.CheckServer(context.Background(), GoogleDnsServer, "www.bing.com")
 	if err != nil {
 		t.Errorf("expect no error but got: %+v", err)
 	}
@@ -166,3 +167,88 @@ func TestCheck(t *testing.T) {
 		t.Errorf("expect 4 results but got %d", len(r))
 	}
 }
+
+func TestCheckWithParams(t *testing.T) {
+	client := &FakeDnsClient{
+		r: &dns.Msg{
+			MsgHdr: dns.MsgHdr{
+				Rcode: dns.RcodeSuccess,
+			},
+		},
+	}
+	checker := &DnsChecker{
+		client: client,
+	}
+
+	ctx := &base.CheckContext{
+		Environment: &env.StaticEnvironment{
+			Flags: []string{"ubuntu"},
+		},
+		Params: map[string]string{
+			"server": "10.2.0.10",
+			"query":  "myapi.internal",
+		},
+	}
+	r, err := checker.Check(ctx)
+	if err != nil {
+		t.Errorf("expect no error but got: %+v", err)
+	}
+	if len(r) != 1 {
+		t.Errorf("expect 1 result but got %d", len(r))
+	}
+	if client.a != "10.2.0.10:53" {
+		t.Errorf("dns request server is wrong: %s", client.a)
+	}
+	if client.m.Question[0].String() != ";myapi.internal.\tIN\t A" {
+		t.Errorf("wrong dns question: %s", client.m.Question[0].String())
+	}
+}
+
+func TestGetCheckTargetsClusterDns(t *testing.T) {
+	e := &env.StaticEnvironment{
+		Flags: []string{"azure"},
+		Facts: env.Facts{ClusterDNS: "10.2.0.10"},
+	}
+	checker := &DnsChecker{}
+	for _, target := range checker.getCheckTargets(e, Config{}) {
+		if target.Name == AksCoreDnsServerInCluster.Name && target.Server != "10.2.0.10" {
+			t.Errorf("expect cluster dns server from environment but got %s", target.Server)
+		}
+	}
+
+	for _, target := range checker.getCheckTargets(e, Config{ClusterDnsServer: "10.3.0.10"}) {
+		if target.Name == AksCoreDnsServerInCluster.Name && target.Server != "10.3.0.10" {
+			t.Errorf("expect configured cluster dns server but got %s", target.Server)
+		}
+		if target.Name == ClusterDnsServer.Name {
+			t.Errorf("expect no extra cluster dns target on azure")
+		}
+	}
+
+	// Not on AKS
+	e = &env.StaticEnvironment{}
+	targets := checker.getCheckTargets(e, Config{ClusterDnsServer: "10.43.0.10"})
+	last := targets[len(targets)-1]
+	if last.Name != ClusterDnsServer.Name || last.Server != "10.43.0.10" {
+		t.Errorf("expect cluster dns target but got %+v", targets)
+	}
+	if targets := checker.getCheckTargets(e, Config{}); len(targets) != 1 {
+		t.Errorf("expect no cluster dns target without cluster dns but got %+v", targets)
+	}
+}
+
+func TestServerAddress(t *testing.T) {
+	tests := map[string]string{
+		"10.0.0.10":      "10.0.0.10:53",
+		"10.0.0.10:5353": "10.0.0.10:5353",
+		"fd00::10":       "[fd00::10]:53",
+		"[fd00::10]":     "[fd00::10]:53",
+		"[fd00::10]:54":  "[fd00::10]:54",
+		"dns.local":      "dns.local:53",
+	}
+	for server, expected := range tests {
+		if address := serverAddress(server); address != expected {
+			t.Errorf("Expect address %s of server %s but got %s", expected, server, address)
+		}
+	}
+}
@@ -26,6 +26,12 @@ func (c *DummyChecker) Name() string {
 	return "Dummy"
 }
 
+func (c *DummyChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description: "A checker that does nothing. Set KDEBUG_DUMMY_FAIL=1 to make it fail.",
+	}
+}
+
 func (c *DummyChecker) Check(_ *base.CheckContext) ([]*base.CheckResult, error) {
 	if os.Getenv("KDEBUG_DUMMY_FAIL") == "1" {
 		return []*base.CheckResult{&failResult}, nil
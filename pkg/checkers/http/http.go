@@ -1,11 +1,14 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/config"
 	"github.com/Azure/kdebug/pkg/env"
 )
 
@@ -24,13 +27,22 @@ var (
 )
 
 type HttpTarget struct {
-	Name   string
-	URL    string
-	Header http.Header
+	Name   string      `yaml:"name"`
+	URL    string      `yaml:"url"`
+	Header http.Header `yaml:"header"`
+	// Expect is the expected status code. Any status code is accepted when unset.
+	Expect int `yaml:"expect"`
+}
+
+// Config is the http section of the config file.
+type Config struct {
+	// Targets replaces the default targets when set.
+	Targets []HttpTarget `yaml:"targets"`
 }
 
 type HttpChecker struct {
 	Client HttpClient
+	config Config
 }
 
 type HttpClient interface {
@@ -47,38 +59,64 @@ func New() *HttpChecker {
 	}
 }
 
+// loadConfig returns the default settings overridden by section.
+func (c *HttpChecker) loadConfig(section *config.Section) (Config, error) {
+	cfg := c.config
+	if section == nil {
+		return cfg, nil
+	}
+	if err := section.Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	for _, target := range cfg.Targets {
+		if target.URL == "" {
+			return cfg, fmt.Errorf("url is required: %+v", target)
+		}
+	}
+	return cfg, nil
+}
+
+func (c *HttpChecker) ValidateConfig(section *config.Section) error {
+	_, err := c.loadConfig(section)
+	return err
+}
+
 func (c *HttpChecker) Name() string {
 	return "Http"
 }
 
+func (c *HttpChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description: "Check HTTP connectivity to well known endpoints and Azure IMDS.",
+		Tags:        []string{"network", "azure"},
+		Parameters: map[string]string{
+			"url":    "URL to probe",
+			"expect": "Expected status code, e.g. 200",
+		},
+	}
+}
+
 func (c *HttpChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	cfg, err := c.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
 	results := []*base.CheckResult{}
-	targets := getCheckTargets(ctx.Environment)
-	var result *base.CheckResult
-	for _, httpTarget := range targets {
-		request, err := http.NewRequest("GET", httpTarget.URL, nil)
+	targets := cfg.Targets
+	if len(targets) == 0 {
+		targets = getCheckTargets(ctx.Environment)
+	}
+	if len(ctx.Params) > 0 {
+		target, err := getParamTarget(ctx.Params)
 		if err != nil {
-			return nil, fmt.Errorf("Fail to create request for target %s: %+v",
-				httpTarget.Name, err)
+			return nil, err
 		}
-		request.Header = httpTarget.Header
-
-		response, err := c.Client.Do(request)
+		targets = []HttpTarget{target}
+	}
+	for _, httpTarget := range targets {
+		result, err := c.CheckTarget(ctx.Ctx(), httpTarget)
 		if err != nil {
-			result = &base.CheckResult{
-				Checker: c.Name(),
-				Error: fmt.Sprintf("Fail to invoke HTTP GET method on URL %s.",
-					httpTarget.URL),
-				Description: err.Error(),
-				//todo: Recommendations and help links
-			}
-		} else {
-			defer response.Body.Close()
-			result = &base.CheckResult{
-				Checker: c.Name(),
-				Description: fmt.Sprintf("Successfully invoke HTTP GET on URL %s , response status code is %s.",
-					httpTarget.URL, response.Status),
-			}
+			return nil, err
 		}
 		results = append(results, result)
 	}
@@ -86,6 +124,61 @@ func (c *HttpChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error)
 	return results, nil
 }
 
+// CheckTarget invokes HTTP GET on the target.
+func (c *HttpChecker) CheckTarget(ctx context.Context, httpTarget HttpTarget) (*base.CheckResult, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", httpTarget.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Fail to create request for target %s: %+v",
+			httpTarget.Name, err)
+	}
+	request.Header = httpTarget.Header
+
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return &base.CheckResult{
+			Checker: c.Name(),
+			Error: fmt.Sprintf("Fail to invoke HTTP GET method on URL %s.",
+				httpTarget.URL),
+			Description: err.Error(),
+			//todo: Recommendations and help links
+		}, nil
+	}
+	defer response.Body.Close()
+	if httpTarget.Expect != 0 && response.StatusCode != httpTarget.Expect {
+		return &base.CheckResult{
+			Checker: c.Name(),
+			Error: fmt.Sprintf("Unexpected status code of HTTP GET on URL %s.",
+				httpTarget.URL),
+			Description: fmt.Sprintf("Expect status code %d but got %s.",
+				httpTarget.Expect, response.Status),
+		}, nil
+	}
+	return &base.CheckResult{
+		Checker: c.Name(),
+		Description: fmt.Sprintf("Successfully invoke HTTP GET on URL %s , response status code is %s.",
+			httpTarget.URL, response.Status),
+	}, nil
+}
+
+// getParamTarget builds an ad-hoc target from command line parameters.
+func getParamTarget(params map[string]string) (HttpTarget, error) {
+	target := HttpTarget{
+		Name: "Specified HTTP endpoint",
+		URL:  params["url"],
+	}
+	if target.URL == "" {
+		return target, fmt.Errorf("Parameter url is required")
+	}
+	if expect, ok := params["expect"]; ok {
+		code, err := strconv.Atoi(expect)
+		if err != nil {
+			return target, fmt.Errorf("Invalid expected status code %q: %s", expect, err)
+		}
+		target.Expect = code
+	}
+	return target, nil
+}
+
 func getCheckTargets(e env.Environment) []HttpTarget {
 	targets := []HttpTarget{
 		GoogleTarget,
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/env"
+)
+
+type fakeHttpClient struct {
+	statusCode int
+	urls       []string
+}
+
+func (c *fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	c.urls = append(c.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: c.statusCode,
+		Status:     http.StatusText(c.statusCode),
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestCheckWithParams(t *testing.T) {
+	client := &fakeHttpClient{statusCode: http.StatusServiceUnavailable}
+	checker := &HttpChecker{Client: client}
+	ctx := &base.CheckContext{
+		Environment: &env.StaticEnvironment{},
+		Params: map[string]string{
+			"url":    "https://foo/healthz",
+			"expect": "200",
+		},
+	}
+
+	results, err := checker.Check(ctx)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 1 || results[0].Ok() {
+		t.Errorf("Expect 1 failed result but got %+v", results)
+	}
+	if len(client.urls) != 1 || client.urls[0] != "https://foo/healthz" {
+		t.Errorf("Unexpected requested urls: %v", client.urls)
+	}
+
+	client.statusCode = http.StatusOK
+	results, _ = checker.Check(ctx)
+	if len(results) != 1 || !results[0].Ok() {
+		t.Errorf("Expect 1 passed result but got %+v", results)
+	}
+
+	ctx.Params = map[string]string{"expect": "200"}
+	if _, err := checker.Check(ctx); err == nil {
+		t.Errorf("Expect error when url is missing")
+	}
+}
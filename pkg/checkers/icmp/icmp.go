@@ -1,14 +1,15 @@
 package icmpping
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	probing "github.com/prometheus-community/pro-bing"
-	log "github.com/sirupsen/logrus"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/config"
 )
 
 var PublicTargets = []pingTarget{
@@ -26,42 +27,84 @@ var PublicTargets = []pingTarget{
 
 type ICMPChecker struct {
 	targets []pingTarget
+	config  Config
 }
 
 type pingTarget struct {
-	Address        string
-	Name           string
-	Recomendations []string
+	Address        string   `yaml:"address"`
+	Name           string   `yaml:"name"`
+	Recomendations []string `yaml:"recommendations"`
+}
+
+// Config is the ping section of the config file.
+type Config struct {
+	// Targets replaces the default public targets when set.
+	Targets []pingTarget `yaml:"targets"`
 }
 
 func New() *ICMPChecker {
 	return &ICMPChecker{}
 }
 
+// loadConfig returns the default settings overridden by section.
+func (c *ICMPChecker) loadConfig(section *config.Section) (Config, error) {
+	cfg := c.config
+	if section == nil {
+		return cfg, nil
+	}
+	if err := section.Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	for _, target := range cfg.Targets {
+		if target.Address == "" {
+			return cfg, fmt.Errorf("address is required: %+v", target)
+		}
+	}
+	return cfg, nil
+}
+
+func (c *ICMPChecker) ValidateConfig(section *config.Section) error {
+	_, err := c.loadConfig(section)
+	return err
+}
+
 func (c *ICMPChecker) Name() string {
 	return "icmp"
 }
 
+func (c *ICMPChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description: "Check if public and cluster DNS IPs reply to ICMP ping.",
+		NeedsRoot:   true,
+		Tags:        []string{"network"},
+		Parameters: map[string]string{
+			"address": "IP or host name to ping",
+		},
+	}
+}
+
 func (c *ICMPChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
-	var results []*base.CheckResult
-	// TODO: Invoke `ping` command if non-root
-	if !ctx.Environment.HasFlag("root") {
-		log.Debug("Not root. Skip ICMP checker")
-		return results, nil
+	cfg, err := c.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
 	}
-	if !ctx.Environment.HasFlag("azure") {
-		c.targets = append(c.targets, PublicTargets...)
+	var results []*base.CheckResult
+	targets := append([]pingTarget{}, c.targets...)
+	if len(cfg.Targets) > 0 {
+		targets = append(targets, cfg.Targets...)
+	} else if !ctx.Environment.HasFlag("azure") {
+		targets = append(targets, PublicTargets...)
 	}
-	if ctx.KubeClient != nil {
-
+	if address, ok := ctx.Params["address"]; ok {
+		targets = []pingTarget{{Address: address, Name: "Specified"}}
 	}
-	resultChan := make(chan *base.CheckResult, len(c.targets))
-	for _, target := range c.targets {
+	resultChan := make(chan *base.CheckResult, len(targets))
+	for _, target := range targets {
 		go func(pingTarget pingTarget) {
 			result := &base.CheckResult{
 				Checker: c.Name(),
 			}
-			err := pingOne(pingTarget.Address)
+			err := pingOne(ctx.Ctx(), pingTarget.Address)
 			if err != nil {
 				result.Error = err.Error()
 				result.Description = fmt.Sprintf("ping %s[%s] failed", pingTarget.Address, pingTarget.Name)
@@ -73,19 +116,29 @@ func (c *ICMPChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error)
 
 		}(target)
 	}
-	for i := 0; i < len(c.targets); i++ {
+	for i := 0; i < len(targets); i++ {
 		result := <-resultChan
 		results = append(results, result)
 	}
 	return results, nil
 }
 
-func pingOne(ip string) error {
+func pingOne(ctx context.Context, ip string) error {
 	pinger, err := probing.NewPinger(ip)
 	if err != nil {
 		return err
 	}
 
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pinger.Stop()
+		case <-done:
+		}
+	}()
+
 	pinger.Count = 3
 	pinger.Interval = time.Millisecond * 20
 	pinger.Timeout = time.Millisecond * 1000
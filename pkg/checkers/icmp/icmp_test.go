@@ -39,24 +39,9 @@ func TestICMPCheckRoot(t *testing.T) {
 	}
 }
 
-func TestICMPCheckNonRoot(t *testing.T) {
-	if os.Geteuid() == 0 {
-		t.Skip("Must run with non-root")
-		return
-	}
-
-	targets := []pingTarget{{Address: "x.x.x.x"},
-		{Address: "127.0.0.1"},
-	}
-	checker := ICMPChecker{targets: targets}
-	context := &base.CheckContext{
-		Environment: &env.StaticEnvironment{
-			Flags: []string{},
-		},
-		KubeClient: nil,
-	}
-	results, _ := checker.Check(context)
-	if len(results) != 0 {
-		t.Errorf("icmp checker unexpected results when not in root mode")
+func TestICMPCheckNeedsRoot(t *testing.T) {
+	checker := New()
+	if !checker.Metadata().NeedsRoot {
+		t.Errorf("icmp checker should require root")
 	}
 }
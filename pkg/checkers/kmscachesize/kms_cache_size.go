@@ -1,7 +1,6 @@
 package kmscachesize
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -10,9 +9,9 @@ import (
 	"github.com/shirou/gopsutil/v3/process"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
 )
 
 var helpLink = []string{
@@ -49,21 +48,19 @@ func New() *KMSCacheSizeChecker {
 	return &KMSCacheSizeChecker{}
 }
 
-func (c *KMSCacheSizeChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
-	if !ctx.Environment.HasFlag("linux") {
-		log.Debugf("Skip %s checker in non-linux os", c.Name())
-		return []*base.CheckResult{}, nil
-	}
-
-	if ctx.KubeClient == nil {
-		log.Debugf("Skip %s checker due to no kube config provided", c.Name())
-		return []*base.CheckResult{}, nil
+func (c *KMSCacheSizeChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:     "Check if API server KMS cache size is sufficient to hold all secrets.",
+		RequiredFlags:   []string{"linux"},
+		NeedsKubeClient: true,
+		Tags:            []string{"kube", "controlplane"},
 	}
+}
 
+func (c *KMSCacheSizeChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
 	kmsConfigPath, err := getKmsConfigPath()
 	if err != nil {
-		log.Debugf("Cannot find KMS config file: %s", err)
-		return []*base.CheckResult{}, nil
+		return c.skip(fmt.Sprintf("Skip KMS cache size check: %s", err)), nil
 	}
 
 	cacheSize, err := getKmsCacheSize(kmsConfigPath)
@@ -74,8 +71,12 @@ func (c *KMSCacheSizeChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult
 	log.Debugf("KMS cache size: %d", cacheSize)
 
 	if cacheSize == 0 {
-		log.Debugf("There's no limit for KMS cache size")
-		return []*base.CheckResult{}, nil
+		return []*base.CheckResult{
+			{
+				Checker:     c.Name(),
+				Description: "There's no limit for KMS cache size.",
+			},
+		}, nil
 	}
 
 	secretsCount, err := c.getCurrentSecretsCount(ctx)
@@ -99,6 +100,16 @@ func (c *KMSCacheSizeChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult
 	return []*base.CheckResult{result}, nil
 }
 
+func (c *KMSCacheSizeChecker) skip(reason string) []*base.CheckResult {
+	return []*base.CheckResult{
+		{
+			Checker:     c.Name(),
+			Status:      base.StatusSkipped,
+			Description: reason,
+		},
+	}
+}
+
 func getKmsConfigPath() (string, error) {
 	procs, err := process.Processes()
 	if err != nil {
@@ -154,10 +165,11 @@ func getKmsCacheSize(path string) (int, error) {
 }
 
 func (c *KMSCacheSizeChecker) getCurrentSecretsCount(ctx *base.CheckContext) (int, error) {
-	client := ctx.KubeClient
-	secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{})
+	// Count secrets of the whole cluster whatever the scope of the run,
+	// since the KMS cache holds all of them.
+	secrets, err := ctx.KubeCache.Secrets(ctx.Ctx(), kubecache.Query{IgnoreScope: true})
 	if err != nil {
 		return 0, fmt.Errorf("Fail to list secrets from Kubernetes: %s", err)
 	}
-	return len(secrets.Items), nil
+	return len(secrets), nil
 }
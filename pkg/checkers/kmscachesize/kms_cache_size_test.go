@@ -0,0 +1,90 @@
+package kmscachesize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
+)
+
+func TestGetKmsCacheSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "encryption.yaml")
+	os.WriteFile(path, []byte(`
+resources:
+  - providers:
+      - kms:
+          cachesize: 1000
+`), 0644)
+	size, err := getKmsCacheSize(path)
+	if err != nil || size != 1000 {
+		t.Errorf("Expect cache size 1000 but got %d, %v", size, err)
+	}
+}
+
+func TestGetCurrentSecretsCount(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "kube-system"}},
+	)
+	ctx := &base.CheckContext{
+		KubeClient: client,
+		KubeCache:  kubecache.New(client),
+	}
+	count, err := New().getCurrentSecretsCount(ctx)
+	if err != nil || count != 2 {
+		t.Errorf("Expect 2 secrets but got %d, %v", count, err)
+	}
+}
+
+func TestGetCurrentSecretsCountIgnoresScope(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "kube-system"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "team-a"}},
+	)
+	cache := kubecache.New(client)
+	cache.Scope = kubecache.Scope{
+		Namespaces:        []string{"default"},
+		ExcludeNamespaces: []string{"kube-system"},
+		LabelSelector:     "app=web",
+	}
+	ctx := &base.CheckContext{
+		KubeClient: client,
+		KubeCache:  cache,
+	}
+	count, err := New().getCurrentSecretsCount(ctx)
+	if err != nil || count != 3 {
+		t.Errorf("Expect 3 secrets of the cluster but got %d, %v", count, err)
+	}
+}
+
+func TestGetCurrentSecretsCountForbidden(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+	)
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "" {
+			return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", fmt.Errorf("cluster scope"))
+		}
+		return false, nil, nil
+	})
+	cache := kubecache.New(client)
+	cache.Scope.FallbackNamespace = "default"
+	ctx := &base.CheckContext{
+		KubeClient: client,
+		KubeCache:  cache,
+	}
+	if count, err := New().getCurrentSecretsCount(ctx); err == nil {
+		t.Errorf("Expect error instead of a partial count but got %d", count)
+	}
+}
@@ -5,11 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 
-	log "github.com/sirupsen/logrus"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/config"
+	"github.com/Azure/kdebug/pkg/kubecache"
 	"github.com/dustin/go-humanize"
 )
 
@@ -17,77 +15,122 @@ const (
 	WarnSizeThreshold = 800 * (1 << 10) // 800 KB
 )
 
+// Config is the kubeobjectsize section of the config file.
+type Config struct {
+	WarnSizeThreshold int `yaml:"warnSizeThreshold"` // In bytes
+}
+
 type KubeObjectSizeChecker struct {
+	config Config
 }
 
 func New() *KubeObjectSizeChecker {
-	return &KubeObjectSizeChecker{}
+	return &KubeObjectSizeChecker{
+		config: Config{
+			WarnSizeThreshold: WarnSizeThreshold,
+		},
+	}
+}
+
+// loadConfig returns the default settings overridden by section.
+func (c *KubeObjectSizeChecker) loadConfig(section *config.Section) (Config, error) {
+	cfg := c.config
+	if section == nil {
+		return cfg, nil
+	}
+	if err := section.Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.WarnSizeThreshold <= 0 {
+		return cfg, fmt.Errorf("warnSizeThreshold must be positive: %d", cfg.WarnSizeThreshold)
+	}
+	return cfg, nil
+}
+
+func (c *KubeObjectSizeChecker) ValidateConfig(section *config.Section) error {
+	_, err := c.loadConfig(section)
+	return err
 }
 
 func (c *KubeObjectSizeChecker) Name() string {
 	return "KubeObjectSize"
 }
 
+func (c *KubeObjectSizeChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:     "Check if configmaps and secrets are reaching the 1MiB size limit.",
+		NeedsKubeClient: true,
+		Tags:            []string{"kube", "cluster"},
+	}
+}
+
 func (c *KubeObjectSizeChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	cfg, err := c.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
 	results := []*base.CheckResult{}
 
-	if ctx.KubeClient != nil {
-		results = append(results, c.checkConfigMaps(ctx.KubeClient)...)
-		results = append(results, c.checkSecrets(ctx.KubeClient)...)
-	} else {
-		log.Warn("Skip KubeObjectSizeChecker due to missing kube client")
+	cmResults, err := c.checkConfigMaps(ctx.Ctx(), ctx.KubeCache, cfg)
+	if err != nil {
+		return results, err
+	}
+	results = append(results, cmResults...)
+	secretResults, err := c.checkSecrets(ctx.Ctx(), ctx.KubeCache, cfg)
+	if err != nil {
+		return results, err
 	}
+	results = append(results, secretResults...)
 
 	return results, nil
 }
 
-func (c *KubeObjectSizeChecker) checkConfigMaps(clientset *kubernetes.Clientset) []*base.CheckResult {
+func (c *KubeObjectSizeChecker) checkConfigMaps(ctx context.Context, cache *kubecache.Cache, cfg Config) ([]*base.CheckResult, error) {
 	results := []*base.CheckResult{}
 
-	cms, err := clientset.CoreV1().ConfigMaps("").List(context.Background(), metav1.ListOptions{})
+	cms, err := cache.ConfigMaps(ctx, kubecache.Query{})
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Warn("Fail to list config maps")
-		return results
+		return results, fmt.Errorf("Fail to list config maps: %s", err)
 	}
 
-	for _, cm := range cms.Items {
-		result := c.checkObjectSize("ConfigMap", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name, cm)
+	for _, cm := range cms {
+		result := c.checkObjectSize("ConfigMap", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name, cm, cfg)
 		if result != nil {
 			results = append(results, result)
 		}
 	}
 
-	return results
+	return results, nil
 }
 
-func (c *KubeObjectSizeChecker) checkSecrets(clientset *kubernetes.Clientset) []*base.CheckResult {
+func (c *KubeObjectSizeChecker) checkSecrets(ctx context.Context, cache *kubecache.Cache, cfg Config) ([]*base.CheckResult, error) {
 	results := []*base.CheckResult{}
 
-	cms, err := clientset.CoreV1().Secrets("").List(context.Background(), metav1.ListOptions{})
+	cms, err := cache.Secrets(ctx, kubecache.Query{})
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Warn("Fail to list secrets")
-		return results
+		return results, fmt.Errorf("Fail to list secrets: %s", err)
 	}
 
-	for _, cm := range cms.Items {
-		result := c.checkObjectSize("Secret", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name, cm)
+	for _, cm := range cms {
+		result := c.checkObjectSize("Secret", cm.ObjectMeta.Namespace, cm.ObjectMeta.Name, cm, cfg)
 		if result != nil {
 			results = append(results, result)
 		}
 	}
 
-	return results
+	return results, nil
 }
 
-func (c *KubeObjectSizeChecker) checkObjectSize(kind, ns, name string, obj interface{}) *base.CheckResult {
+func (c *KubeObjectSizeChecker) checkObjectSize(kind, ns, name string, obj interface{}, cfg Config) *base.CheckResult {
 	data, err := json.Marshal(obj)
 	if err != nil {
 		return nil
 	}
 
-	if len(data) > WarnSizeThreshold {
+	if len(data) > cfg.WarnSizeThreshold {
 		return &base.CheckResult{
 			Checker:     c.Name(),
+			Status:      base.StatusWarn,
 			Error:       fmt.Sprintf("%s %s/%s reaching size limit.", kind, ns, name),
 			Description: fmt.Sprintf("%s %s/%s of size %s is reaching size limit. It cannot exceed 1MiB.", kind, ns, name, humanize.Bytes(uint64(len(data)))),
 			Recommendations: []string{
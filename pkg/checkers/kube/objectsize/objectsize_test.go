@@ -1,9 +1,17 @@
 package dns
 
 import (
+	"errors"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
 )
 
 func TestCheckObjectSize_OK(t *testing.T) {
@@ -13,7 +21,7 @@ func TestCheckObjectSize_OK(t *testing.T) {
 		},
 	}
 	checker := New()
-	result := checker.checkObjectSize("ConfigMap", "default", "cm", cm)
+	result := checker.checkObjectSize("ConfigMap", "default", "cm", cm, checker.config)
 	if !result.Ok() {
 		t.Errorf("Expect ok result but got %+v", result)
 	}
@@ -26,11 +34,44 @@ func TestCheckObjectSize_Warn(t *testing.T) {
 		},
 	}
 	checker := New()
-	result := checker.checkObjectSize("ConfigMap", "default", "cm", cm)
-	if result.Ok() {
-		t.Errorf("Expect non ok result but got %+v", result)
+	result := checker.checkObjectSize("ConfigMap", "default", "cm", cm, checker.config)
+	if result.Ok() || result.Status != base.StatusWarn {
+		t.Errorf("Expect warning result but got %+v", result)
 	}
 	if result.Error == "" || result.Description == "" || len(result.Recommendations) == 0 {
 		t.Errorf("Expect non empty result but got %+v", result)
 	}
 }
+
+func TestCheck(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "small", Namespace: "default"},
+			Data:       map[string]string{"key": "value"},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "large", Namespace: "kube-system"},
+			Data:       map[string][]byte{"key": make([]byte, WarnSizeThreshold)},
+		},
+	)
+	ctx := &base.CheckContext{KubeClient: client, KubeCache: kubecache.New(client)}
+
+	results, err := New().Check(ctx)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expect 2 results but got %d", len(results))
+	}
+	if !results[0].Ok() || results[1].Status != base.StatusWarn {
+		t.Errorf("Expect small config map ok and large secret warned but got %+v, %+v", results[0], results[1])
+	}
+
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	ctx.KubeCache = kubecache.New(client)
+	if _, err := New().Check(ctx); err == nil {
+		t.Errorf("Expect error when listing secrets fails")
+	}
+}
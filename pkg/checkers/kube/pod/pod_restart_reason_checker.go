@@ -2,7 +2,6 @@ package pod
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -10,18 +9,15 @@ import (
 	"time"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
 	log "github.com/sirupsen/logrus"
 
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/duration"
-	runtimeresource "k8s.io/cli-runtime/pkg/resource"
-	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/reference"
-	"k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/describe"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubectl/pkg/util/qos"
@@ -40,21 +36,23 @@ func (c *KubePodRestartReasonChecker) Name() string {
 	return "KubePodRestartReason"
 }
 
-// Check borrows many logic and helper functions from src/k8s.io/kubectl/pkg/describe to check Pod status and events.
-func (c *KubePodRestartReasonChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
-	if ctx.KubeClient == nil {
-		log.Warn("Skip KubePodRestartReasonChecker due to missing kube client")
-		return nil, nil
+func (c *KubePodRestartReasonChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:     "Check restart reasons and events of crash looping pods.",
+		NeedsKubeClient: true,
+		Tags:            []string{"kube", "cluster"},
 	}
+}
 
-	pods, err := ctx.KubeClient.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+// Check borrows many logic and helper functions from src/k8s.io/kubectl/pkg/describe to check Pod status and events.
+func (c *KubePodRestartReasonChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	pods, err := ctx.KubeCache.Pods(ctx.Ctx(), kubecache.Query{})
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Warn("Fail to list pods")
-		return nil, err
+		return nil, fmt.Errorf("Fail to list pods: %s", err)
 	}
 
 	results := []*base.CheckResult{}
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		var crashing = false
 		for _, containerStatus := range pod.Status.ContainerStatuses {
 			if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == "CrashLoopBackOff" {
@@ -86,7 +84,7 @@ func (c *KubePodRestartReasonChecker) checkPod(ctx *base.CheckContext, pod *v1.P
 	if _, isMirrorPod := pod.Annotations[corev1.MirrorPodAnnotationKey]; isMirrorPod {
 		ref.UID = types.UID(pod.Annotations[corev1.MirrorPodAnnotationKey])
 	}
-	events, _ = searchEvents(ctx.KubeClient.CoreV1(), ref, util.DefaultChunkSize)
+	events, _ = podEvents(ctx, ref)
 	text, _ := describePodStatus(pod, events)
 	logs := strings.Split(text, "\n")
 
@@ -158,36 +156,21 @@ func tabbedString(f func(io.Writer) error) (string, error) {
 	return str, nil
 }
 
-func searchEvents(client corev1client.EventsGetter, objOrRef runtime.Object, limit int64) (*corev1.EventList, error) {
-	ref, err := reference.GetReference(scheme.Scheme, objOrRef)
+// podEvents filters events of the pod namespace, which are listed once and
+// shared among pods, by the involved object.
+func podEvents(ctx *base.CheckContext, ref *corev1.ObjectReference) (*corev1.EventList, error) {
+	events, err := ctx.KubeCache.Events(ctx.Ctx(), kubecache.Query{Namespace: ref.Namespace})
 	if err != nil {
 		return nil, err
 	}
-	stringRefKind := string(ref.Kind)
-	var refKind *string
-	if len(stringRefKind) > 0 {
-		refKind = &stringRefKind
-	}
-	stringRefUID := string(ref.UID)
-	var refUID *string
-	if len(stringRefUID) > 0 {
-		refUID = &stringRefUID
-	}
-
-	e := client.Events(ref.Namespace)
-	fieldSelector := e.GetFieldSelector(&ref.Name, &ref.Namespace, refKind, refUID)
-	initialOpts := metav1.ListOptions{FieldSelector: fieldSelector.String(), Limit: limit}
 	eventList := &corev1.EventList{}
-	err = runtimeresource.FollowContinue(&initialOpts,
-		func(options metav1.ListOptions) (runtime.Object, error) {
-			newEvents, err := e.List(context.TODO(), options)
-			if err != nil {
-				return nil, runtimeresource.EnhanceListError(err, options, "events")
-			}
-			eventList.Items = append(eventList.Items, newEvents.Items...)
-			return newEvents, nil
-		})
-	return eventList, err
+	for _, event := range events {
+		obj := event.InvolvedObject
+		if obj.Namespace == ref.Namespace && obj.Name == ref.Name && (ref.UID == "" || obj.UID == ref.UID) {
+			eventList.Items = append(eventList.Items, event)
+		}
+	}
+	return eventList, nil
 }
 
 func describeContainers(label string, containers []corev1.Container, containerStatuses []corev1.ContainerStatus,
@@ -0,0 +1,74 @@
+package pod
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
+)
+
+func TestCheck(t *testing.T) {
+	crashing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "crashing", Namespace: "default", UID: "uid-1"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app:v1"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "app",
+					RestartCount: 5,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+				},
+			},
+		},
+	}
+	healthy := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  "app",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				},
+			},
+		},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "crashing.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "crashing", Namespace: "default", UID: "uid-1"},
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Type:           corev1.EventTypeWarning,
+	}
+	client := fake.NewSimpleClientset(crashing, healthy, event)
+	ctx := &base.CheckContext{
+		KubeClient: client,
+		KubeCache:  kubecache.New(client),
+	}
+
+	results, err := New().Check(ctx)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expect 1 result but got %d", len(results))
+	}
+	r := results[0]
+	if r.Ok() || !strings.Contains(r.Error, "default/crashing") {
+		t.Errorf("Expect failed result of crashing pod but got %+v", r)
+	}
+	logs := strings.Join(r.Logs, "\n")
+	if !strings.Contains(logs, "CrashLoopBackOff") || !strings.Contains(logs, "Back-off restarting failed container") {
+		t.Errorf("Expect container state and events in logs but got:\n%s", logs)
+	}
+}
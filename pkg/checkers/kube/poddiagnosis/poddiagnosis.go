@@ -0,0 +1,458 @@
+package poddiagnosis
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
+)
+
+const Tag = "pod"
+
+var imagePullReasons = map[string]bool{
+	"ErrImagePull":      true,
+	"ImagePullBackOff":  true,
+	"InvalidImageName":  true,
+	"ErrImageNeverPull": true,
+}
+
+// PodDiagnosisChecker looks into a single pod and what it depends on.
+type PodDiagnosisChecker struct {
+}
+
+func New() *PodDiagnosisChecker {
+	return &PodDiagnosisChecker{}
+}
+
+func (c *PodDiagnosisChecker) Name() string {
+	return "PodDiagnosis"
+}
+
+func (c *PodDiagnosisChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:     "Diagnose the pod given with --pod: containers, events, scheduling, node, images, probes, volumes and services.",
+		NeedsKubeClient: true,
+		NeedsPod:        true,
+		Tags:            []string{"kube", Tag},
+	}
+}
+
+func (c *PodDiagnosisChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	pod, err := ctx.KubeClient.CoreV1().Pods(ctx.Pod.Namespace).Get(ctx.Ctx(), ctx.Pod.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return []*base.CheckResult{
+			{
+				Checker:     c.Name(),
+				Error:       fmt.Sprintf("Pod %s not found", ctx.Pod),
+				Description: fmt.Sprintf("Pod %s does not exist. It may have been deleted or replaced.", ctx.Pod),
+			},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Fail to get pod %s: %s", ctx.Pod, err)
+	}
+
+	events, err := podEvents(ctx, pod)
+	if err != nil {
+		log.Warnf("Fail to list events of pod %s: %s", ctx.Pod, err)
+	}
+
+	results := []*base.CheckResult{c.checkScheduling(pod)}
+	results = append(results, c.checkContainers(pod)...)
+	results = append(results, c.checkImages(pod, events))
+	results = append(results, c.checkProbes(pod, events))
+	if result := c.checkNode(ctx, pod); result != nil {
+		results = append(results, result)
+	}
+	results = append(results, c.checkVolumes(ctx, pod)...)
+	results = append(results, c.checkServices(ctx, pod)...)
+	results = append(results, c.checkEvents(pod, events))
+	return results, nil
+}
+
+func podEvents(ctx *base.CheckContext, pod *corev1.Pod) ([]corev1.Event, error) {
+	events, err := ctx.KubeCache.Events(ctx.Ctx(), kubecache.Query{
+		Namespace:     pod.Namespace,
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", pod.Name).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Events of an earlier pod with the same name are not relevant
+	podEvents := []corev1.Event{}
+	for _, event := range events {
+		if event.InvolvedObject.Name == pod.Name && (event.InvolvedObject.UID == "" || event.InvolvedObject.UID == pod.UID) {
+			podEvents = append(podEvents, event)
+		}
+	}
+	return podEvents, nil
+}
+
+func podName(pod *corev1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+func getPodCondition(pod *corev1.Pod, t corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == t {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func (c *PodDiagnosisChecker) checkScheduling(pod *corev1.Pod) *base.CheckResult {
+	cond := getPodCondition(pod, corev1.PodScheduled)
+	if cond != nil && cond.Status == corev1.ConditionFalse {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Error:       fmt.Sprintf("Pod %s is not scheduled: %s", podName(pod), cond.Reason),
+			Description: fmt.Sprintf("Scheduler cannot place pod %s: %s", podName(pod), cond.Message),
+			Recommendations: []string{
+				"Check resource requests, node selectors, affinity, taints and tolerations of the pod against available nodes.",
+			},
+			HelpLinks: []string{
+				"https://kubernetes.io/docs/concepts/scheduling-eviction/assign-pod-node/",
+			},
+		}
+	}
+	if pod.Spec.NodeName == "" {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Status:      base.StatusWarn,
+			Error:       fmt.Sprintf("Pod %s is not scheduled yet", podName(pod)),
+			Description: fmt.Sprintf("Pod %s is waiting to be scheduled to a node.", podName(pod)),
+		}
+	}
+	return &base.CheckResult{
+		Checker:     c.Name(),
+		Description: fmt.Sprintf("Pod %s is scheduled to node %s.", podName(pod), pod.Spec.NodeName),
+	}
+}
+
+func (c *PodDiagnosisChecker) checkContainers(pod *corev1.Pod) []*base.CheckResult {
+	results := []*base.CheckResult{}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		results = append(results, c.checkContainer(pod, status))
+	}
+	return results
+}
+
+func (c *PodDiagnosisChecker) checkContainer(pod *corev1.Pod, status corev1.ContainerStatus) *base.CheckResult {
+	name := fmt.Sprintf("Container %s of pod %s", status.Name, podName(pod))
+	logs := []string{
+		fmt.Sprintf("Image: %s", status.Image),
+		fmt.Sprintf("Restart count: %d", status.RestartCount),
+	}
+	recommendations := []string{}
+	if last := status.LastTerminationState.Terminated; last != nil {
+		logs = append(logs, fmt.Sprintf("Last terminated: %s (exit code %d) at %s. %s",
+			last.Reason, last.ExitCode, last.FinishedAt.Time.Format(metav1.RFC3339Micro), last.Message))
+		if last.Reason == "OOMKilled" {
+			recommendations = append(recommendations, "The container was killed for running out of memory. Consider raising its memory limit.")
+		}
+	}
+	if status.RestartCount > 0 {
+		recommendations = append(recommendations, fmt.Sprintf("Check logs of the previous run with: kubectl logs -n %s %s -c %s --previous", pod.Namespace, pod.Name, status.Name))
+	}
+
+	result := &base.CheckResult{
+		Checker:         c.Name(),
+		Logs:            logs,
+		Recommendations: recommendations,
+	}
+	switch state := status.State; {
+	case state.Waiting != nil && imagePullReasons[state.Waiting.Reason]:
+		// Reported by checkImages
+		result.Status = base.StatusWarn
+		result.Error = fmt.Sprintf("%s is waiting for its image", name)
+		result.Description = fmt.Sprintf("%s is waiting: %s", name, state.Waiting.Reason)
+	case state.Waiting != nil && state.Waiting.Reason != "" && state.Waiting.Reason != "ContainerCreating" && state.Waiting.Reason != "PodInitializing":
+		result.Error = fmt.Sprintf("%s is waiting: %s", name, state.Waiting.Reason)
+		result.Description = fmt.Sprintf("%s is waiting: %s %s", name, state.Waiting.Reason, state.Waiting.Message)
+	case state.Terminated != nil && state.Terminated.ExitCode != 0:
+		result.Error = fmt.Sprintf("%s terminated: %s (exit code %d)", name, state.Terminated.Reason, state.Terminated.ExitCode)
+		result.Description = fmt.Sprintf("%s terminated with exit code %d. %s", name, state.Terminated.ExitCode, state.Terminated.Message)
+	case state.Terminated != nil:
+		result.Description = fmt.Sprintf("%s completed.", name)
+	case state.Running != nil && !status.Ready:
+		result.Status = base.StatusWarn
+		result.Error = fmt.Sprintf("%s is running but not ready", name)
+		result.Description = fmt.Sprintf("%s is running but not ready. Its readiness probe may be failing.", name)
+	case status.RestartCount > 0:
+		result.Status = base.StatusWarn
+		result.Error = fmt.Sprintf("%s restarted %d times", name, status.RestartCount)
+		result.Description = fmt.Sprintf("%s is running but restarted %d times.", name, status.RestartCount)
+	case state.Running != nil:
+		result.Description = fmt.Sprintf("%s is running and ready.", name)
+	default:
+		result.Status = base.StatusWarn
+		result.Error = fmt.Sprintf("%s is not started yet", name)
+		result.Description = fmt.Sprintf("%s is being created.", name)
+	}
+	return result
+}
+
+func (c *PodDiagnosisChecker) checkImages(pod *corev1.Pod, events []corev1.Event) *base.CheckResult {
+	failing := []string{}
+	logs := []string{}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && imagePullReasons[status.State.Waiting.Reason] {
+			failing = append(failing, status.Image)
+			logs = append(logs, fmt.Sprintf("%s: %s %s", status.Name, status.State.Waiting.Reason, status.State.Waiting.Message))
+		}
+	}
+	if len(failing) == 0 {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Description: fmt.Sprintf("No image pull failures of pod %s.", podName(pod)),
+		}
+	}
+	for _, event := range events {
+		if event.Reason == "Failed" && strings.Contains(strings.ToLower(event.Message), "pull") {
+			logs = append(logs, event.Message)
+		}
+	}
+	return &base.CheckResult{
+		Checker:     c.Name(),
+		Error:       fmt.Sprintf("Fail to pull images of pod %s: %s", podName(pod), strings.Join(failing, ", ")),
+		Description: fmt.Sprintf("Images %s of pod %s cannot be pulled.", strings.Join(failing, ", "), podName(pod)),
+		Logs:        logs,
+		Recommendations: []string{
+			"Check the image name and tag exist in the registry.",
+			"Check imagePullSecrets of the pod or its service account if the registry is private.",
+			"Check the node can reach the registry.",
+		},
+		HelpLinks: []string{
+			"https://kubernetes.io/docs/concepts/containers/images/#imagepullbackoff",
+		},
+	}
+}
+
+func (c *PodDiagnosisChecker) checkProbes(pod *corev1.Pod, events []corev1.Event) *base.CheckResult {
+	logs := []string{}
+	for _, event := range events {
+		if event.Reason == "Unhealthy" {
+			logs = append(logs, fmt.Sprintf("%s (x%d)", event.Message, eventCount(event)))
+		}
+	}
+	if len(logs) == 0 {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Description: fmt.Sprintf("No probe failures of pod %s.", podName(pod)),
+		}
+	}
+	return &base.CheckResult{
+		Checker:     c.Name(),
+		Status:      base.StatusWarn,
+		Error:       fmt.Sprintf("Probes of pod %s are failing", podName(pod)),
+		Description: fmt.Sprintf("Liveness, readiness or startup probes of pod %s failed %d times recently.", podName(pod), len(logs)),
+		Logs:        logs,
+		Recommendations: []string{
+			"Check the probe endpoints respond in time, or raise timeoutSeconds, initialDelaySeconds or failureThreshold.",
+		},
+		HelpLinks: []string{
+			"https://kubernetes.io/docs/tasks/configure-pod-container/configure-liveness-readiness-startup-probes/",
+		},
+	}
+}
+
+func (c *PodDiagnosisChecker) checkNode(ctx *base.CheckContext, pod *corev1.Pod) *base.CheckResult {
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	node, err := ctx.KubeClient.CoreV1().Nodes().Get(ctx.Ctx(), pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Status:      base.StatusWarn,
+			Error:       fmt.Sprintf("Fail to get node %s: %s", pod.Spec.NodeName, err),
+			Description: fmt.Sprintf("Health of node %s hosting pod %s is unknown.", pod.Spec.NodeName, podName(pod)),
+		}
+	}
+
+	problems := []string{}
+	ready := false
+	for _, cond := range node.Status.Conditions {
+		switch cond.Type {
+		case corev1.NodeReady:
+			ready = cond.Status == corev1.ConditionTrue
+			if !ready {
+				problems = append(problems, fmt.Sprintf("NotReady: %s", cond.Message))
+			}
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure, corev1.NodeNetworkUnavailable:
+			if cond.Status == corev1.ConditionTrue {
+				problems = append(problems, fmt.Sprintf("%s: %s", cond.Type, cond.Message))
+			}
+		}
+	}
+	if node.Spec.Unschedulable {
+		problems = append(problems, "Node is cordoned")
+	}
+
+	if !ready {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Error:       fmt.Sprintf("Node %s hosting pod %s is not ready", node.Name, podName(pod)),
+			Description: fmt.Sprintf("Node %s is not ready, so pod %s may not run on it.", node.Name, podName(pod)),
+			Logs:        problems,
+			Recommendations: []string{
+				fmt.Sprintf("Run kdebug on node %s, e.g. kdebug --batch.machines %s, to find out why.", node.Name, node.Name),
+			},
+		}
+	}
+	if len(problems) > 0 {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Status:      base.StatusWarn,
+			Error:       fmt.Sprintf("Node %s hosting pod %s is unhealthy", node.Name, podName(pod)),
+			Description: fmt.Sprintf("Node %s is ready but has problems that may evict or slow down pod %s.", node.Name, podName(pod)),
+			Logs:        problems,
+		}
+	}
+	return &base.CheckResult{
+		Checker:     c.Name(),
+		Description: fmt.Sprintf("Node %s hosting pod %s is healthy.", node.Name, podName(pod)),
+	}
+}
+
+func (c *PodDiagnosisChecker) checkVolumes(ctx *base.CheckContext, pod *corev1.Pod) []*base.CheckResult {
+	results := []*base.CheckResult{}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		name := volume.PersistentVolumeClaim.ClaimName
+		pvc, err := ctx.KubeClient.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx.Ctx(), name, metav1.GetOptions{})
+		if err != nil {
+			results = append(results, &base.CheckResult{
+				Checker:     c.Name(),
+				Error:       fmt.Sprintf("Fail to get PVC %s/%s: %s", pod.Namespace, name, err),
+				Description: fmt.Sprintf("Volume %s of pod %s claims PVC %s which cannot be found.", volume.Name, podName(pod), name),
+			})
+			continue
+		}
+		if pvc.Status.Phase != corev1.ClaimBound {
+			results = append(results, &base.CheckResult{
+				Checker:     c.Name(),
+				Error:       fmt.Sprintf("PVC %s/%s is %s", pod.Namespace, name, pvc.Status.Phase),
+				Description: fmt.Sprintf("PVC %s used by pod %s is not bound to a volume.", name, podName(pod)),
+				Recommendations: []string{
+					"Check the storage class of the PVC exists and its provisioner is running.",
+					fmt.Sprintf("Check events with: kubectl describe pvc -n %s %s", pod.Namespace, name),
+				},
+			})
+			continue
+		}
+		results = append(results, &base.CheckResult{
+			Checker:     c.Name(),
+			Description: fmt.Sprintf("PVC %s used by pod %s is bound to volume %s.", name, podName(pod), pvc.Spec.VolumeName),
+		})
+	}
+	return results
+}
+
+func (c *PodDiagnosisChecker) checkServices(ctx *base.CheckContext, pod *corev1.Pod) []*base.CheckResult {
+	results := []*base.CheckResult{}
+	// Labels of services are unrelated to the pod, so the --selector scope doesn't apply
+	services, err := ctx.KubeCache.Services(ctx.Ctx(), kubecache.Query{Namespace: pod.Namespace, IgnoreScopeLabels: true})
+	if err != nil {
+		log.Warnf("Fail to list services of namespace %s: %s", pod.Namespace, err)
+		return results
+	}
+	for _, svc := range services {
+		if len(svc.Spec.Selector) == 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		results = append(results, c.checkEndpoints(ctx, pod, svc.Name))
+	}
+	return results
+}
+
+func (c *PodDiagnosisChecker) checkEndpoints(ctx *base.CheckContext, pod *corev1.Pod, svc string) *base.CheckResult {
+	endpoints, err := ctx.KubeClient.CoreV1().Endpoints(pod.Namespace).Get(ctx.Ctx(), svc, metav1.GetOptions{})
+	if err != nil {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Status:      base.StatusWarn,
+			Error:       fmt.Sprintf("Fail to get endpoints of service %s/%s: %s", pod.Namespace, svc, err),
+			Description: fmt.Sprintf("Pod %s is selected by service %s but its endpoints are unknown.", podName(pod), svc),
+		}
+	}
+	isPod := func(addr corev1.EndpointAddress) bool {
+		if addr.TargetRef != nil {
+			return addr.TargetRef.UID == pod.UID || (addr.TargetRef.Kind == "Pod" && addr.TargetRef.Name == pod.Name)
+		}
+		return pod.Status.PodIP != "" && addr.IP == pod.Status.PodIP
+	}
+	for _, subset := range endpoints.Subsets {
+		for _, addr := range subset.Addresses {
+			if isPod(addr) {
+				return &base.CheckResult{
+					Checker:     c.Name(),
+					Description: fmt.Sprintf("Pod %s is a ready endpoint of service %s.", podName(pod), svc),
+				}
+			}
+		}
+		for _, addr := range subset.NotReadyAddresses {
+			if isPod(addr) {
+				return &base.CheckResult{
+					Checker:     c.Name(),
+					Status:      base.StatusWarn,
+					Error:       fmt.Sprintf("Pod %s is a not ready endpoint of service %s", podName(pod), svc),
+					Description: fmt.Sprintf("Service %s does not route traffic to pod %s because the pod is not ready.", svc, podName(pod)),
+					Recommendations: []string{
+						"Check readiness probes and containers of the pod.",
+					},
+				}
+			}
+		}
+	}
+	return &base.CheckResult{
+		Checker:     c.Name(),
+		Error:       fmt.Sprintf("Pod %s is not an endpoint of service %s", podName(pod), svc),
+		Description: fmt.Sprintf("Service %s selects pod %s but does not route traffic to it.", svc, podName(pod)),
+		Recommendations: []string{
+			"Check the pod is running and has an IP, and that ports of the service match ports of the pod.",
+		},
+	}
+}
+
+func (c *PodDiagnosisChecker) checkEvents(pod *corev1.Pod, events []corev1.Event) *base.CheckResult {
+	logs := []string{}
+	for _, event := range events {
+		if event.Type == corev1.EventTypeWarning {
+			logs = append(logs, fmt.Sprintf("%s: %s (x%d)", event.Reason, event.Message, eventCount(event)))
+		}
+	}
+	if len(logs) == 0 {
+		return &base.CheckResult{
+			Checker:     c.Name(),
+			Description: fmt.Sprintf("No warning events of pod %s.", podName(pod)),
+		}
+	}
+	return &base.CheckResult{
+		Checker:     c.Name(),
+		Status:      base.StatusWarn,
+		Error:       fmt.Sprintf("Pod %s has %d warning events", podName(pod), len(logs)),
+		Description: fmt.Sprintf("Recent warning events of pod %s.", podName(pod)),
+		Logs:        logs,
+	}
+}
+
+func eventCount(event corev1.Event) int32 {
+	if event.Count > 0 {
+		return event.Count
+	}
+	return 1
+}
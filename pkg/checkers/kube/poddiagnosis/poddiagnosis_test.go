@@ -0,0 +1,200 @@
+package poddiagnosis
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
+)
+
+func newContext(pod base.PodRef, client *fake.Clientset) *base.CheckContext {
+	return &base.CheckContext{
+		Pod:        pod,
+		KubeClient: client,
+		KubeCache:  kubecache.New(client),
+	}
+}
+
+func findResult(results []*base.CheckResult, substr string) *base.CheckResult {
+	for _, r := range results {
+		if strings.Contains(r.Error, substr) || strings.Contains(r.Description, substr) {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default", UID: "uid-1", Labels: map[string]string{"app": "web"}},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+			},
+		},
+		Status: corev1.PodStatus{
+			PodIP: "10.0.0.5",
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "app",
+					Image:        "app:v1",
+					Ready:        true,
+					RestartCount: 2,
+					State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137},
+					},
+				},
+				{
+					Name:  "sidecar",
+					Image: "sidecar:nope",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				},
+			},
+		},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue, Message: "disk is full"},
+			},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}
+	other := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "db"}},
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{
+			{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.5"}}},
+		},
+	}
+	events := []*corev1.Event{
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web-0.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-0", Namespace: "default", UID: "uid-1"},
+			Reason:         "Unhealthy",
+			Message:        "Readiness probe failed: connection refused",
+			Type:           corev1.EventTypeWarning,
+			Count:          3,
+		},
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web-0.2", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-0", Namespace: "default", UID: "uid-1"},
+			Reason:         "Failed",
+			Message:        "Failed to pull image \"sidecar:nope\": not found",
+			Type:           corev1.EventTypeWarning,
+		},
+		{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web-0.3", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-0", Namespace: "default", UID: "uid-old"},
+			Reason:         "Unhealthy",
+			Message:        "Liveness probe failed of an earlier pod",
+			Type:           corev1.EventTypeWarning,
+		},
+	}
+	client := fake.NewSimpleClientset(pod, node, pvc, svc, other, endpoints, events[0], events[1], events[2])
+
+	results, err := New().Check(newContext(base.PodRef{Namespace: "default", Name: "web-0"}, client))
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+
+	tests := []struct {
+		substr string
+		status base.Status
+	}{
+		{"scheduled to node node-1", base.StatusPass},
+		{"Container app of pod default/web-0 restarted 2 times", base.StatusWarn},
+		{"Container sidecar of pod default/web-0 is waiting for its image", base.StatusWarn},
+		{"Fail to pull images of pod default/web-0: sidecar:nope", base.StatusFail},
+		{"Probes of pod default/web-0 are failing", base.StatusWarn},
+		{"Node node-1 hosting pod default/web-0 is unhealthy", base.StatusWarn},
+		{"PVC default/data is Pending", base.StatusFail},
+		{"not ready endpoint of service web", base.StatusWarn},
+		{"has 2 warning events", base.StatusWarn},
+	}
+	for _, test := range tests {
+		r := findResult(results, test.substr)
+		if r == nil {
+			t.Errorf("Expect result %q in %+v", test.substr, results)
+			continue
+		}
+		if r.GetStatus() != test.status {
+			t.Errorf("Expect status %s of %q but got %s", test.status, test.substr, r.GetStatus())
+		}
+	}
+	if findResult(results, "service db") != nil {
+		t.Errorf("Expect no result of service not selecting the pod")
+	}
+	app := findResult(results, "Container app")
+	if !strings.Contains(strings.Join(app.Recommendations, " "), "memory limit") {
+		t.Errorf("Expect OOM recommendation but got %v", app.Recommendations)
+	}
+}
+
+func TestCheckUnscheduled(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable", Message: "0/3 nodes are available: 3 Insufficient cpu."},
+			},
+		},
+	}
+	results, err := New().Check(newContext(base.PodRef{Namespace: "default", Name: "web-0"}, fake.NewSimpleClientset(pod)))
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	r := findResult(results, "is not scheduled")
+	if r == nil || r.Ok() || !strings.Contains(r.Description, "Insufficient cpu") {
+		t.Errorf("Expect failed scheduling result but got %+v", r)
+	}
+}
+
+func TestCheckNotFound(t *testing.T) {
+	results, err := New().Check(newContext(base.PodRef{Namespace: "default", Name: "gone"}, fake.NewSimpleClientset()))
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 1 || results[0].Ok() || !strings.Contains(results[0].Error, "default/gone not found") {
+		t.Errorf("Expect a not found result but got %+v", results)
+	}
+}
+
+func TestCheckServicesWithScopeSelector(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default", Labels: map[string]string{"app": "web", "team": "a"}},
+		Status:     corev1.PodStatus{PodIP: "10.0.0.5"},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+	}
+	client := fake.NewSimpleClientset(pod, svc)
+	ctx := newContext(base.PodRef{Namespace: "default", Name: "web-0"}, client)
+	ctx.KubeCache.Scope.LabelSelector = "team=a"
+
+	results := New().checkServices(ctx, pod)
+	if findResult(results, "service web") == nil {
+		t.Errorf("Expect result of service web without the labels of --selector but got %+v", results)
+	}
+}
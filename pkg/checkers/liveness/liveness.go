@@ -25,10 +25,18 @@ func (c *LivenessChecker) Name() string {
 	return CheckerName
 }
 
+func (c *LivenessChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:   "Check if kubelet service is running.",
+		RequiredFlags: []string{"linux"},
+		Tags:          []string{"node", "kube"},
+	}
+}
+
 func (c *LivenessChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
 	results := []*base.CheckResult{}
 
-	out, err := exec.Command("systemctl", "status", "kubelet").Output()
+	out, err := exec.CommandContext(ctx.Ctx(), "systemctl", "status", "kubelet").Output()
 
 	if err != nil {
 		log.Debugf("systemctl status returned non-zero exit code: %+v", err)
@@ -65,8 +73,9 @@ func parseOutput(output []byte) *base.CheckResult {
 	}
 
 	return &base.CheckResult{
-		Checker: CheckerName,
-		Error:   "Kubelet is NOT running well in this node. Please check the logs for more details.",
-		Logs:    rows,
+		Checker:  CheckerName,
+		Severity: base.SeverityCritical,
+		Error:    "Kubelet is NOT running well in this node. Please check the logs for more details.",
+		Logs:     rows,
 	}
 }
@@ -16,6 +16,7 @@ import (
 const (
 	kmsgLogPath     = "/dev/kmsg"
 	ubuntuLogPath   = "/var/log/kern.log"
+	rhelLogPath     = "/var/log/messages"
 	cgroupOOMKeyStr = "Memory cgroup out of memory"
 	outOfMemoryKey  = "Out of memory"
 )
@@ -37,7 +38,7 @@ func (c *OOMChecker) Name() string {
 }
 
 func New() *OOMChecker {
-	paths := []string{kmsgLogPath, ubuntuLogPath}
+	paths := []string{kmsgLogPath, ubuntuLogPath, rhelLogPath}
 	for _, path := range paths {
 		if file, err := os.Open(path); err == nil {
 			file.Close()
@@ -51,6 +52,14 @@ func New() *OOMChecker {
 	}
 }
 
+func (c *OOMChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:   "Check kernel logs for processes killed by the OOM killer.",
+		RequiredFlags: []string{"linux"},
+		Tags:          []string{"node", "memory"},
+	}
+}
+
 func (c *OOMChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
 	var results []*base.CheckResult
 	oomResult, err := c.checkOOM(ctx)
@@ -65,12 +74,8 @@ func (c *OOMChecker) checkOOM(ctx *base.CheckContext) (*base.CheckResult, error)
 	result := &base.CheckResult{
 		Checker: c.Name(),
 	}
-	//todo:support other os
-	if !ctx.Environment.HasFlag("linux") {
-		result.Description = fmt.Sprint("Skip oom check in non-linux os")
-		return result, nil
-	}
 	if c.kernLogPath == "" {
+		result.Status = base.StatusSkipped
 		result.Description = fmt.Sprint("Skip oom check because of can't access supported kern log path")
 		return result, nil
 	}
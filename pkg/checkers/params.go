@@ -0,0 +1,90 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParseCheckerSpecs parses checker specs given on the command line. A spec is
+// a checker name optionally followed by parameters, e.g. dns:server=10.0.0.10,query=example.com.
+// Values can contain commas unless followed by a parameter name and "=".
+// It returns the checker names and the parameters by checker name.
+func ParseCheckerSpecs(specs []string) ([]string, map[string]map[string]string, error) {
+	names := make([]string, 0, len(specs))
+	params := map[string]map[string]string{}
+	for _, spec := range specs {
+		name, paramStr := spec, ""
+		if i := strings.Index(spec, ":"); i >= 0 {
+			name, paramStr = spec[:i], spec[i+1:]
+		}
+		checker, ok := allCheckers[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("Unknown checker: %s", name)
+		}
+		names = append(names, name)
+		if paramStr == "" {
+			continue
+		}
+
+		if _, ok := params[name]; ok {
+			return nil, nil, fmt.Errorf("Parameters of checker %s are specified more than once", name)
+		}
+		accepted := checker.Metadata().Parameters
+		params[name] = map[string]string{}
+		for _, kv := range splitParams(paramStr, accepted) {
+			i := strings.Index(kv, "=")
+			if i <= 0 {
+				return nil, nil, fmt.Errorf("Invalid parameter %q of checker %s. Expect key=value", kv, name)
+			}
+			key, value := kv[:i], kv[i+1:]
+			if _, ok := accepted[key]; !ok {
+				return nil, nil, fmt.Errorf("Checker %s does not accept parameter %s. Accepted: %v",
+					name, key, sortedKeys(accepted))
+			}
+			params[name][key] = value
+		}
+	}
+	return names, params, nil
+}
+
+// splitParams splits key=value pairs by commas. A comma that is not followed
+// by an accepted key and "=" is part of the value, e.g. url=https://foo/?ids=1,2.
+func splitParams(paramStr string, accepted map[string]string) []string {
+	kvs := []string{}
+	for _, part := range strings.Split(paramStr, ",") {
+		i := strings.Index(part, "=")
+		if len(kvs) > 0 && (i <= 0 || !hasKey(accepted, part[:i])) {
+			kvs[len(kvs)-1] += "," + part
+			continue
+		}
+		kvs = append(kvs, part)
+	}
+	return kvs
+}
+
+func hasKey(m map[string]string, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// FormatCheckerSpec is the reverse of ParseCheckerSpecs for a single checker.
+func FormatCheckerSpec(name string, params map[string]string) string {
+	if len(params) == 0 {
+		return name
+	}
+	kvs := make([]string, 0, len(params))
+	for _, key := range sortedKeys(params) {
+		kvs = append(kvs, key+"="+params[key])
+	}
+	return name + ":" + strings.Join(kvs, ",")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,201 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Azure/kdebug/pkg/base"
+)
+
+const (
+	// MetadataSuffix is the suffix of the optional metadata file next to a plugin,
+	// e.g. mycheck.yaml for mycheck.
+	MetadataSuffix = ".yaml"
+	Tag            = "plugin"
+)
+
+// Request is written to the stdin of a plugin as JSON. The plugin writes
+// a JSON array of check results to stdout.
+type Request struct {
+	// Environment are the environment flags, e.g. linux, azure.
+	Environment []string          `json:"environment"`
+	Params      map[string]string `json:"params"`
+}
+
+// Metadata is read from the optional metadata file of a plugin.
+type Metadata struct {
+	Description   string            `yaml:"description"`
+	Tags          []string          `yaml:"tags"`
+	Parameters    map[string]string `yaml:"parameters"`
+	RequiredFlags []string          `yaml:"requiredFlags"`
+	NeedsRoot     bool              `yaml:"needsRoot"`
+}
+
+// PluginChecker runs an external executable as a checker.
+type PluginChecker struct {
+	name         string
+	Path         string
+	MetadataPath string
+	metadata     Metadata
+}
+
+func DefaultDirs() []string {
+	dirs := []string{}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".kdebug", "plugins"))
+	}
+	return append(dirs, "/etc/kdebug/plugins.d")
+}
+
+// Discover finds plugins in dirs. Plugins found in earlier dirs take precedence.
+func Discover(dirs []string) []*PluginChecker {
+	plugins := []*PluginChecker{}
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Warnf("Fail to read plugin dir %s: %s", dir, err)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			fileName := entry.Name()
+			if strings.HasPrefix(fileName, ".") || strings.HasSuffix(fileName, MetadataSuffix) {
+				continue
+			}
+			path := filepath.Join(dir, fileName)
+			// Follow symlinks, e.g. files of a mounted config map
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || info.Mode()&0111 == 0 {
+				continue
+			}
+			name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+			if seen[name] {
+				log.Debugf("Ignore plugin %s shadowed by a plugin with the same name", path)
+				continue
+			}
+			plugin, err := newPluginChecker(name, path)
+			if err != nil {
+				log.Warnf("Ignore plugin %s: %s", path, err)
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+func newPluginChecker(name, path string) (*PluginChecker, error) {
+	c := &PluginChecker{
+		name: name,
+		Path: path,
+	}
+	metadataPath := strings.TrimSuffix(path, filepath.Ext(path)) + MetadataSuffix
+	data, err := os.ReadFile(metadataPath)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Fail to read metadata file %s: %s", metadataPath, err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c.metadata); err != nil {
+		return nil, fmt.Errorf("Fail to parse metadata file %s: %s", metadataPath, err)
+	}
+	c.MetadataPath = metadataPath
+	return c, nil
+}
+
+// Files returns the plugin and its metadata file if any.
+func (c *PluginChecker) Files() []string {
+	if c.MetadataPath == "" {
+		return []string{c.Path}
+	}
+	return []string{c.Path, c.MetadataPath}
+}
+
+func (c *PluginChecker) Name() string {
+	return c.name
+}
+
+func (c *PluginChecker) Metadata() base.CheckerMetadata {
+	description := c.metadata.Description
+	if description == "" {
+		description = "Plugin " + c.Path
+	}
+	return base.CheckerMetadata{
+		Description:   description,
+		RequiredFlags: c.metadata.RequiredFlags,
+		NeedsRoot:     c.metadata.NeedsRoot,
+		Tags:          append([]string{Tag}, c.metadata.Tags...),
+		Parameters:    c.metadata.Parameters,
+	}
+}
+
+func (c *PluginChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	req := Request{
+		Environment: []string{},
+		Params:      ctx.Params,
+	}
+	if ctx.Environment != nil {
+		req.Environment = ctx.Environment.GetFlags()
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("Fail to marshal plugin request: %s", err)
+	}
+
+	// The process is killed when the checker times out
+	cmd := exec.CommandContext(ctx.Ctx(), c.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	logs := splitLines(stderr.String())
+	log.WithFields(log.Fields{"plugin": c.Path, "stderr": logs}).Debug("Plugin finished")
+	if err != nil {
+		return nil, fmt.Errorf("Plugin %s failed: %s. Stderr: %s", c.Path, err, strings.Join(logs, "\n"))
+	}
+
+	var results []*base.CheckResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		return nil, fmt.Errorf("Fail to parse output of plugin %s: %s", c.Path, err)
+	}
+	for i, r := range results {
+		if r == nil {
+			return nil, fmt.Errorf("Plugin %s returned a null result", c.Path)
+		}
+		if !r.Status.Valid() || !r.Severity.Valid() {
+			results[i] = &base.CheckResult{
+				Status:      base.StatusErrored,
+				Error:       fmt.Sprintf("Plugin %s returned a result with unknown status %q or severity %q", c.Path, r.Status, r.Severity),
+				Description: "The plugin output is invalid, so the state it checks is unknown.",
+			}
+			r = results[i]
+		}
+		// Plugins can't report results of other checkers
+		r.Checker = c.name
+		r.Logs = append(r.Logs, logs...)
+	}
+	return results, nil
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,117 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/env"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("Fail to write plugin: %s", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	writePlugin(t, dir1, "foo.sh", "")
+	writePlugin(t, dir2, "foo", "")
+	writePlugin(t, dir2, "bar", "")
+	os.WriteFile(filepath.Join(dir2, "bar.yaml"), []byte("description: Bar\nparameters:\n  host: Host\n"), 0644)
+	os.WriteFile(filepath.Join(dir2, "notexec"), []byte(""), 0644)
+
+	plugins := Discover([]string{dir1, filepath.Join(dir1, "nosuchdir"), dir2})
+	if len(plugins) != 2 {
+		t.Fatalf("Expect 2 plugins but got %d", len(plugins))
+	}
+	if plugins[0].Name() != "foo" || plugins[0].Path != filepath.Join(dir1, "foo.sh") {
+		t.Errorf("Expect foo of first dir but got %s", plugins[0].Path)
+	}
+	meta := plugins[1].Metadata()
+	if plugins[1].Name() != "bar" || meta.Description != "Bar" || meta.Parameters["host"] != "Host" {
+		t.Errorf("Unexpected plugin bar: %+v", meta)
+	}
+	if len(plugins[1].Files()) != 2 {
+		t.Errorf("Expect plugin bar with metadata file but got %v", plugins[1].Files())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "echo", `
+input=$(cat)
+echo "checking" >&2
+echo "[{\"Status\":\"warn\",\"Description\":\"$(echo $input | tr -d '"')\"}]"
+`)
+	c := Discover([]string{dir})[0]
+	ctx := &base.CheckContext{
+		Environment: &env.StaticEnvironment{Flags: []string{"linux"}},
+		Params:      map[string]string{"host": "foo"},
+	}
+	results, err := c.Check(ctx)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expect 1 result but got %d", len(results))
+	}
+	r := results[0]
+	if r.Checker != "echo" || r.Status != base.StatusWarn {
+		t.Errorf("Unexpected result: %+v", r)
+	}
+	if r.Description != "{environment:[linux],params:{host:foo}}" {
+		t.Errorf("Unexpected request: %s", r.Description)
+	}
+	if len(r.Logs) != 1 || r.Logs[0] != "checking" {
+		t.Errorf("Expect stderr in logs but got %v", r.Logs)
+	}
+}
+
+func TestCheckError(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "fail", "echo oops >&2\nexit 1\n")
+	writePlugin(t, dir, "badoutput", "echo notjson\n")
+	writePlugin(t, dir, "slow", "exec sleep 10\n")
+	writePlugin(t, dir, "null", "echo '[null]'\n")
+
+	for _, c := range Discover([]string{dir}) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		_, err := c.Check(&base.CheckContext{Context: ctx})
+		cancel()
+		if err == nil {
+			t.Errorf("Expect error of plugin %s", c.Name())
+		}
+		if c.Name() == "fail" && !strings.Contains(err.Error(), "oops") {
+			t.Errorf("Expect stderr in error but got %s", err)
+		}
+	}
+}
+
+func TestCheckInvalidResults(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "invalid", `echo '[{"Checker":"Dns","Status":"pass"},{"Status":"ok!"},{"Status":"fail","Severity":"urgent"}]'`)
+	results, err := Discover([]string{dir})[0].Check(&base.CheckContext{})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expect 3 results but got %d", len(results))
+	}
+	for i, r := range results {
+		if r.Checker != "invalid" {
+			t.Errorf("Expect checker named after the plugin but got %s", r.Checker)
+		}
+		if i > 0 && r.Status != base.StatusErrored {
+			t.Errorf("Expect errored result of invalid status or severity but got %+v", r)
+		}
+	}
+	if !strings.Contains(results[1].Error, "ok!") || !strings.Contains(results[2].Error, "urgent") {
+		t.Errorf("Expect bad values in errors but got %q, %q", results[1].Error, results[2].Error)
+	}
+}
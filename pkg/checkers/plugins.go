@@ -0,0 +1,29 @@
+package checker
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/Azure/kdebug/pkg/checkers/plugin"
+)
+
+// LoadPlugins registers plugin checkers found in dirs.
+// Built-in checkers take precedence over plugins with the same name.
+func LoadPlugins(dirs []string) {
+	for _, p := range plugin.Discover(dirs) {
+		if err := Register(p.Name(), p); err != nil {
+			log.Warnf("Ignore plugin %s: %s", p.Path, err)
+		}
+	}
+}
+
+// PluginFiles returns files of plugin checkers among names,
+// which need to be copied to remote machines in batch mode.
+func PluginFiles(names []string) []string {
+	files := []string{}
+	for _, name := range names {
+		if p, ok := allCheckers[name].(*plugin.PluginChecker); ok {
+			files = append(files, p.Files()...)
+		}
+	}
+	return files
+}
@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 
-	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
 )
 
 type PodScheduleChecker struct {
@@ -23,31 +21,30 @@ func (c *PodScheduleChecker) Name() string {
 	return "PodSchedule"
 }
 
-func (c *PodScheduleChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
-	results := []*base.CheckResult{}
-
-	if ctx.KubeClient != nil {
-		results = append(results, c.checkPodSchedule(ctx.KubeClient)...)
-	} else {
-		log.Debugf("Skip %s due to missing Kubernetes config", c.Name())
+func (c *PodScheduleChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:     "Check if all pods of a replica set are scheduled on the same node.",
+		NeedsKubeClient: true,
+		Tags:            []string{"kube", "cluster"},
 	}
+}
 
-	return results, nil
+func (c *PodScheduleChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	return c.checkPodSchedule(ctx.Ctx(), ctx.KubeCache)
 }
 
-func (c *PodScheduleChecker) checkPodSchedule(clientset *kubernetes.Clientset) []*base.CheckResult {
+func (c *PodScheduleChecker) checkPodSchedule(ctx context.Context, cache *kubecache.Cache) ([]*base.CheckResult, error) {
 	results := []*base.CheckResult{}
 
 	// List all pods
-	pods, err := clientset.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+	pods, err := cache.Pods(ctx, kubecache.Query{})
 	if err != nil {
-		log.WithFields(log.Fields{"error": err}).Warn("Fail to list pods")
-		return results
+		return results, fmt.Errorf("Fail to list pods: %s", err)
 	}
 
 	// Group pods by replicaset
 	podsByRs := make(map[string][]corev1.Pod)
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		if pod.ObjectMeta.OwnerReferences == nil || len(pod.ObjectMeta.OwnerReferences) == 0 {
 			continue
 		}
@@ -74,7 +71,7 @@ func (c *PodScheduleChecker) checkPodSchedule(clientset *kubernetes.Clientset) [
 		results = append(results, c.checkPodsScheduleInReplicaSet(rsName, rsPods))
 	}
 
-	return results
+	return results, nil
 }
 
 func (c *PodScheduleChecker) checkPodsScheduleInReplicaSet(rsName string, pods []corev1.Pod) *base.CheckResult {
@@ -1,9 +1,18 @@
 package podschedule
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/kubecache"
 )
 
 func TestPodSchedule_Single_Panic(t *testing.T) {
@@ -66,3 +75,58 @@ func TestPodSchedule_Failed(t *testing.T) {
 		t.Errorf("Expect failed result but got %+v", result)
 	}
 }
+
+func newReplicaSetPod(ns, name, rs, node string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: rs},
+			},
+		},
+		Spec: v1.PodSpec{
+			NodeName: node,
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newReplicaSetPod("default", "web-1", "web", "a"),
+		newReplicaSetPod("default", "web-2", "web", "a"),
+		newReplicaSetPod("default", "api-1", "api", "a"),
+		newReplicaSetPod("default", "api-2", "api", "b"),
+		newReplicaSetPod("default", "single-1", "single", "a"),
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "standalone", Namespace: "default"}},
+	)
+	ctx := &base.CheckContext{KubeClient: client, KubeCache: kubecache.New(client)}
+
+	results, err := New().Check(ctx)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expect 2 results but got %d", len(results))
+	}
+	failed := 0
+	for _, r := range results {
+		if !r.Ok() {
+			failed++
+			if !strings.Contains(r.Error, "default/web") {
+				t.Errorf("Expect replica set web failed but got %s", r.Error)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expect 1 failed result but got %d", failed)
+	}
+
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	ctx.KubeCache = kubecache.New(client)
+	if _, err := New().Check(ctx); err == nil {
+		t.Errorf("Expect error when listing pods fails")
+	}
+}
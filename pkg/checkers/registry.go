@@ -1,8 +1,11 @@
 package checker
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
+	"github.com/Azure/kdebug/pkg/base"
 	"github.com/Azure/kdebug/pkg/checkers/diskreadonly"
 	"github.com/Azure/kdebug/pkg/checkers/diskusage"
 	"github.com/Azure/kdebug/pkg/checkers/dns"
@@ -12,6 +15,7 @@ import (
 	"github.com/Azure/kdebug/pkg/checkers/kmscachesize"
 	kubeobjectsize "github.com/Azure/kdebug/pkg/checkers/kube/objectsize"
 	"github.com/Azure/kdebug/pkg/checkers/kube/pod"
+	"github.com/Azure/kdebug/pkg/checkers/kube/poddiagnosis"
 	"github.com/Azure/kdebug/pkg/checkers/liveness"
 	"github.com/Azure/kdebug/pkg/checkers/oom"
 	"github.com/Azure/kdebug/pkg/checkers/podschedule"
@@ -34,6 +38,20 @@ var allCheckers = map[string]Checker{
 	"systemload":     systemload.New(),
 	"kmscachesize":   kmscachesize.New(),
 	"podschedule":    podschedule.New(),
+	"poddiagnosis":   poddiagnosis.New(),
+}
+
+// Register adds a checker to the registry, e.g. from a custom binary that
+// bundles its own checkers. It must be called before running checks.
+func Register(name string, checker Checker) error {
+	if name == "" || strings.ContainsAny(name, ":,=") {
+		return fmt.Errorf("Invalid checker name: %q", name)
+	}
+	if _, ok := allCheckers[name]; ok {
+		return fmt.Errorf("Checker %s already exists", name)
+	}
+	allCheckers[name] = checker
+	return nil
 }
 
 func ListAllCheckerNames() []string {
@@ -44,3 +62,84 @@ func ListAllCheckerNames() []string {
 	sort.Strings(names)
 	return names
 }
+
+func GetCheckerMetadata(name string) (base.CheckerMetadata, error) {
+	if checker, ok := allCheckers[name]; ok {
+		return checker.Metadata(), nil
+	}
+	return base.CheckerMetadata{}, fmt.Errorf("Unknown checker: %s", name)
+}
+
+func ListAllTags() []string {
+	tagSet := map[string]struct{}{}
+	for _, checker := range allCheckers {
+		for _, tag := range checker.Metadata().Tags {
+			tagSet[tag] = struct{}{}
+		}
+	}
+	tags := make([]string, 0, len(tagSet))
+	for t := range tagSet {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SelectCheckers resolves checker names from explicit names and groups (tags),
+// then removes skipped names. All checkers are selected when neither names
+// nor groups are given.
+func SelectCheckers(names, groups, skips []string) ([]string, error) {
+	selected := []string{}
+	seen := map[string]bool{}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, name)
+		}
+	}
+
+	for _, name := range names {
+		add(name)
+	}
+	for _, group := range groups {
+		found := false
+		for _, name := range ListAllCheckerNames() {
+			if hasTag(allCheckers[name].Metadata(), group) {
+				add(name)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown checker group: %s. Available groups: %v", group, ListAllTags())
+		}
+	}
+	if len(names) == 0 && len(groups) == 0 {
+		for _, name := range ListAllCheckerNames() {
+			add(name)
+		}
+	}
+
+	skipSet := map[string]bool{}
+	for _, name := range skips {
+		if _, ok := allCheckers[name]; !ok {
+			return nil, fmt.Errorf("Unknown checker to skip: %s", name)
+		}
+		skipSet[name] = true
+	}
+	result := make([]string, 0, len(selected))
+	for _, name := range selected {
+		if !skipSet[name] {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+func hasTag(meta base.CheckerMetadata, tag string) bool {
+	for _, t := range meta.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package checker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectCheckers(t *testing.T) {
+	names, err := SelectCheckers(nil, nil, nil)
+	if err != nil || !reflect.DeepEqual(names, ListAllCheckerNames()) {
+		t.Errorf("Expect all checkers but got %v, %v", names, err)
+	}
+
+	names, err = SelectCheckers([]string{"oom"}, []string{"network"}, []string{"ping"})
+	if err != nil {
+		t.Fatalf("Expect no error but got: %+v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"oom", "dns", "http", "tcp"}) {
+		t.Errorf("Unexpected selected checkers: %v", names)
+	}
+
+	names, err = SelectCheckers(nil, nil, []string{"kubeobjectsize"})
+	if err != nil || len(names) != len(ListAllCheckerNames())-1 {
+		t.Errorf("Expect all checkers except kubeobjectsize but got %v, %v", names, err)
+	}
+
+	if _, err := SelectCheckers(nil, []string{"nosuchgroup"}, nil); err == nil {
+		t.Errorf("Expect error for unknown group")
+	}
+	if _, err := SelectCheckers(nil, nil, []string{"nosuchchecker"}); err == nil {
+		t.Errorf("Expect error for unknown skipped checker")
+	}
+}
+
+func TestParseCheckerSpecs(t *testing.T) {
+	names, params, err := ParseCheckerSpecs([]string{"oom", "dns:server=10.2.0.10,query=myapi.internal"})
+	if err != nil {
+		t.Fatalf("Expect no error but got: %+v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"oom", "dns"}) {
+		t.Errorf("Unexpected checker names: %v", names)
+	}
+	expected := map[string]map[string]string{
+		"dns": {"server": "10.2.0.10", "query": "myapi.internal"},
+	}
+	if !reflect.DeepEqual(params, expected) {
+		t.Errorf("Unexpected params: %v", params)
+	}
+	if spec := FormatCheckerSpec("dns", params["dns"]); spec != "dns:query=myapi.internal,server=10.2.0.10" {
+		t.Errorf("Unexpected formatted spec: %s", spec)
+	}
+
+	_, params, err = ParseCheckerSpecs([]string{"http:url=https://svc/api?ids=1,2&a=b=c,expect=200"})
+	expected = map[string]map[string]string{
+		"http": {"url": "https://svc/api?ids=1,2&a=b=c", "expect": "200"},
+	}
+	if err != nil || !reflect.DeepEqual(params, expected) {
+		t.Errorf("Expect commas in values but got %v, %v", params, err)
+	}
+	if _, params, err = ParseCheckerSpecs([]string{FormatCheckerSpec("http", expected["http"])}); err != nil || !reflect.DeepEqual(params, expected) {
+		t.Errorf("Expect formatted spec parsed back but got %v, %v", params, err)
+	}
+
+	for _, specs := range [][]string{
+		{"nosuchchecker"},
+		{"dns:nosuchparam=1"},
+		{"dns:server"},
+		{"oom:foo=bar"},
+		{"dns:server=a", "dns:server=b"},
+	} {
+		if _, _, err := ParseCheckerSpecs(specs); err == nil {
+			t.Errorf("Expect error for %v", specs)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/config"
 	linuxproc "github.com/c9s/goprocinfo/linux"
 )
 
@@ -39,27 +40,72 @@ type InterestedProc struct {
 }
 
 type ProcLimitMeasurement struct {
-	CPULimitAsGloabl     float64 // The percentage compare to the whole VM CPU capacity. 100 means using up all the cpu capacity
-	CPULimitAsSingleCore float64 // The percentage compare to one core. 100 means using up 1 core's capacity. Maximum number can be 100 * cores
+	CPULimitAsGloabl     float64 `yaml:"cpuLimitAsGlobal"`     // The percentage compare to the whole VM CPU capacity. 100 means using up all the cpu capacity
+	CPULimitAsSingleCore float64 `yaml:"cpuLimitAsSingleCore"` // The percentage compare to one core. 100 means using up 1 core's capacity. Maximum number can be 100 * cores
+}
+
+// Config is the systemload section of the config file.
+type Config struct {
+	CPUPercentageLimit    float64                         `yaml:"cpuPercentageLimit"`
+	MemoryPercentageLimit float64                         `yaml:"memoryPercentageLimit"`
+	Processes             map[string]ProcLimitMeasurement `yaml:"processes"` // Replaces the default interested processes
 }
 
 type SystemLoadChecker struct {
+	config Config
 }
 
 func New() *SystemLoadChecker {
-	return &SystemLoadChecker{}
+	return &SystemLoadChecker{
+		config: Config{
+			CPUPercentageLimit:    VMCPUPercentageLimit,
+			MemoryPercentageLimit: VMMemoryPercentageLimit,
+			Processes:             InterestedProcNames,
+		},
+	}
+}
+
+// loadConfig returns the default settings overridden by section.
+func (c *SystemLoadChecker) loadConfig(section *config.Section) (Config, error) {
+	cfg := c.config
+	if section == nil {
+		return cfg, nil
+	}
+	cfg.Processes = nil
+	if err := section.Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Processes == nil {
+		cfg.Processes = c.config.Processes
+	}
+	return cfg, nil
+}
+
+func (c *SystemLoadChecker) ValidateConfig(section *config.Section) error {
+	_, err := c.loadConfig(section)
+	return err
 }
 
 func (c *SystemLoadChecker) Name() string {
 	return "SystemLoad"
 }
 
-func (c *SystemLoadChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
-	result := []*base.CheckResult{}
+func (c *SystemLoadChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description:   "Check CPU and memory usage of the VM and of primary processes like etcd and kubelet.",
+		RequiredFlags: []string{"linux"},
+		// CPU sampling must not see load from other checkers
+		Exclusive: true,
+		Tags:      []string{"node", "cpu", "memory"},
+	}
+}
 
-	if !ctx.Environment.HasFlag("linux") {
-		return result, nil
+func (c *SystemLoadChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	cfg, err := c.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
 	}
+	result := []*base.CheckResult{}
 
 	// VM Memory
 	memInfo, err := linuxproc.ReadMemInfo("/proc/meminfo")
@@ -67,15 +113,15 @@ func (c *SystemLoadChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult,
 		return result, err
 	}
 	var memUsage = getMemPercentage(memInfo.MemAvailable, memInfo.MemTotal)
-	if memUsage > VMMemoryPercentageLimit {
+	if memUsage > cfg.MemoryPercentageLimit {
 		result = append(result, &base.CheckResult{
 			Checker:     c.Name(),
-			Error:       fmt.Sprintf(GlobalMemoryTooHigh, memUsage, VMMemoryPercentageLimit),
+			Error:       fmt.Sprintf(GlobalMemoryTooHigh, memUsage, cfg.MemoryPercentageLimit),
 			Description: GloablHighMemoryRecommandation,
 		})
 	}
 
-	interestedProcesses, err := getInterestedProc()
+	interestedProcesses, err := getInterestedProc(cfg.Processes)
 	if err != nil {
 		return result, err
 	}
@@ -90,7 +136,11 @@ func (c *SystemLoadChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult,
 	previousIdleTime, previousTotalTime := getSystemCPUTime(stat.CPUStatAll)
 
 	// Sleep a time span and check cpu time again to get average CPU load
-	time.Sleep(time.Duration(CPUSpan * float64(time.Second)))
+	select {
+	case <-time.After(time.Duration(CPUSpan * float64(time.Second))):
+	case <-ctx.Ctx().Done():
+		return result, ctx.Ctx().Err()
+	}
 
 	stat, err = linuxproc.ReadStat("/proc/stat")
 	if err != nil {
@@ -103,10 +153,10 @@ func (c *SystemLoadChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult,
 	var usage = getSystemCPUPercentage(deltaSystemIdleTime, deltaSystemTotalTime)
 
 	// VM CPU
-	if usage > VMCPUPercentageLimit {
+	if usage > cfg.CPUPercentageLimit {
 		result = append(result, &base.CheckResult{
 			Checker:     c.Name(),
-			Error:       fmt.Sprintf(GlobalCPUTooHigh, usage, VMCPUPercentageLimit),
+			Error:       fmt.Sprintf(GlobalCPUTooHigh, usage, cfg.CPUPercentageLimit),
 			Description: GloablHighCPURecommandation,
 		})
 	}
@@ -140,7 +190,7 @@ func getTotalTime(stat linuxproc.CPUStat) uint64 {
 		stat.Steal + stat.Guest + stat.GuestNice
 }
 
-func getInterestedProc() ([]*InterestedProc, error) {
+func getInterestedProc(procNames map[string]ProcLimitMeasurement) ([]*InterestedProc, error) {
 	result := []*InterestedProc{}
 
 	procStatusFiles, err := filepath.Glob("/proc/[0-9]*/stat")
@@ -156,7 +206,7 @@ func getInterestedProc() ([]*InterestedProc, error) {
 		}
 
 		var cmd = stat.Comm[1 : len(stat.Comm)-1] // name: (cmd)
-		if limit, ok := InterestedProcNames[cmd]; ok {
+		if limit, ok := procNames[cmd]; ok {
 			result = append(result, &InterestedProc{
 				StatFilePath:         f,
 				Name:                 cmd,
@@ -11,6 +11,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/config"
 )
 
 const KubernetesServiceHost = "KUBERNETES_SERVICE_HOST"
@@ -25,13 +26,20 @@ var PublicTargets = []pingEndpoint{
 }
 
 type pingEndpoint struct {
-	ServerAddress string
-	Name          string
-	NameSpace     string
+	ServerAddress string `yaml:"address"`
+	Name          string `yaml:"name"`
+	NameSpace     string `yaml:"-"`
 }
 
-func (t *TCPChecker) ping(serverAddr string) error {
-	conn, err := t.dialer.Dial("tcp", serverAddr)
+// Config is the tcp section of the config file.
+type Config struct {
+	// Targets replaces the default public targets when set.
+	Targets []pingEndpoint `yaml:"targets"`
+}
+
+// Ping checks if a TCP connection can be established to the address.
+func (t *TCPChecker) Ping(ctx context.Context, serverAddr string) error {
+	conn, err := t.dialer.DialContext(ctx, "tcp", serverAddr)
 	if err != nil {
 		return err
 	}
@@ -43,6 +51,7 @@ func (t *TCPChecker) ping(serverAddr string) error {
 type TCPChecker struct {
 	dialer  net.Dialer
 	targets []pingEndpoint
+	config  Config
 }
 
 func New() *TCPChecker {
@@ -53,20 +62,67 @@ func New() *TCPChecker {
 	}
 }
 
+// loadConfig returns the default settings overridden by section.
+func (t *TCPChecker) loadConfig(section *config.Section) (Config, error) {
+	cfg := t.config
+	if section == nil {
+		return cfg, nil
+	}
+	if err := section.Decode(&cfg); err != nil {
+		return cfg, err
+	}
+	for _, target := range cfg.Targets {
+		if _, _, err := net.SplitHostPort(target.ServerAddress); err != nil {
+			return cfg, fmt.Errorf("invalid target address %q: %s", target.ServerAddress, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (t *TCPChecker) ValidateConfig(section *config.Section) error {
+	_, err := t.loadConfig(section)
+	return err
+}
+
 func (t *TCPChecker) Name() string {
 	return "TcpChecker"
 }
 
+func (t *TCPChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description: "Check if TCP connections can be established to public endpoints.",
+		Tags:        []string{"network"},
+		Parameters: map[string]string{
+			"address": "Address to connect to, e.g. myservice:443",
+		},
+	}
+}
+
 func (t *TCPChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	cfg, err := t.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
 	var results []*base.CheckResult
-	targets := append(t.targets, getCheckTargets(ctx)...)
+	targets := append([]pingEndpoint{}, t.targets...)
+	if len(cfg.Targets) > 0 {
+		targets = append(targets, cfg.Targets...)
+	} else {
+		targets = append(targets, getCheckTargets(ctx)...)
+	}
+	if address, ok := ctx.Params["address"]; ok {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return nil, fmt.Errorf("Invalid address %q: %s", address, err)
+		}
+		targets = []pingEndpoint{{ServerAddress: address, Name: "Specified"}}
+	}
 	resultChan := make(chan *base.CheckResult, len(targets))
 	for _, pingTarget := range targets {
 		go func(target pingEndpoint) {
 			result := &base.CheckResult{
 				Checker: t.Name(),
 			}
-			err := t.ping(target.ServerAddress)
+			err := t.Ping(ctx.Ctx(), target.ServerAddress)
 			sb := strings.Builder{}
 			if err != nil {
 				sb.WriteString(fmt.Sprintf("Fail to establish tcp connection to %s (%s) ",
@@ -107,7 +163,7 @@ func getCheckTargets(c *base.CheckContext) []pingEndpoint {
 }
 
 func getServicePingEndpoint(c *base.CheckContext) ([]pingEndpoint, error) {
-	services, err := c.KubeClient.CoreV1().Services("").List(context.TODO(), metav1.ListOptions{})
+	services, err := c.KubeClient.CoreV1().Services("").List(c.Ctx(), metav1.ListOptions{})
 	isInKubernetes := c.Environment.HasFlag("k8s")
 	if err != nil {
 		return nil, err
@@ -39,3 +39,28 @@ func TestCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckWithParams(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Fail to listen: %s", err)
+	}
+	defer listener.Close()
+
+	checker := New()
+	ctx := &base.CheckContext{
+		Params: map[string]string{"address": listener.Addr().String()},
+	}
+	results, err := checker.Check(ctx)
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if len(results) != 1 || !results[0].Ok() {
+		t.Errorf("Expect 1 passed result but got %+v", results)
+	}
+
+	ctx.Params = map[string]string{"address": "noport"}
+	if _, err := checker.Check(ctx); err == nil {
+		t.Errorf("Expect error for address without port")
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of a kdebug configuration file, e.g.
+//
+//	checkers:
+//	  diskusage:
+//	    threshold: 80
+type Config struct {
+	// Checkers holds the settings of each checker keyed by checker name.
+	Checkers map[string]*Section `yaml:"checkers"`
+}
+
+// Section is a part of the configuration that is decoded by its owner.
+type Section struct {
+	node yaml.Node
+}
+
+func (s *Section) UnmarshalYAML(node *yaml.Node) error {
+	s.node = *node
+	return nil
+}
+
+// Decode decodes the section into out. Unknown keys are reported as errors.
+func (s *Section) Decode(out interface{}) error {
+	data, err := yaml.Marshal(&s.node)
+	if err != nil {
+		return err
+	}
+	return decodeStrict(data, out)
+}
+
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Fail to read config file %s: %s", path, err)
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("Fail to parse config file %s: %s", path, err)
+	}
+	return config, nil
+}
+
+func Parse(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := decodeStrict(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func decodeStrict(data []byte, out interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(out)
+	if errors.Is(err, io.EOF) {
+		// Empty document
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+)
+
+type testSettings struct {
+	Threshold int      `yaml:"threshold"`
+	Targets   []string `yaml:"targets"`
+}
+
+func TestParse(t *testing.T) {
+	config, err := Parse([]byte(`
+checkers:
+  test:
+    threshold: 80
+    targets: [a, b]
+`))
+	if err != nil {
+		t.Fatalf("Expect no error but got: %+v", err)
+	}
+
+	settings := testSettings{Threshold: 90}
+	if err := config.Checkers["test"].Decode(&settings); err != nil {
+		t.Fatalf("Expect no error but got: %+v", err)
+	}
+	if settings.Threshold != 80 || len(settings.Targets) != 2 {
+		t.Errorf("Unexpected settings: %+v", settings)
+	}
+}
+
+func TestParseUnknownKeys(t *testing.T) {
+	if _, err := Parse([]byte("unknown: 1\n")); err == nil {
+		t.Errorf("Expect error for unknown top level key")
+	}
+
+	config, err := Parse([]byte(`
+checkers:
+  test:
+    threshld: 80
+`))
+	if err != nil {
+		t.Fatalf("Expect no error but got: %+v", err)
+	}
+	if err := config.Checkers["test"].Decode(&testSettings{}); err == nil {
+		t.Errorf("Expect error for unknown checker setting")
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	config, err := Parse([]byte(""))
+	if err != nil || len(config.Checkers) != 0 {
+		t.Errorf("Expect empty config but got %+v, %+v", config, err)
+	}
+}
@@ -1,46 +1,58 @@
 package env
 
 import (
+	"encoding/json"
 	"net/http"
-	"os"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 const (
 	AzureIMDSEndpoint = "http://169.254.169.254/metadata"
+	// azureChassisAssetTag is the DMI chassis asset tag of Azure VMs.
+	azureChassisAssetTag = "7783-7084-3265-9085-8269-3286-77"
 )
 
-func getAzureFlags() []string {
+type imdsInstance struct {
+	Compute struct {
+		Location string `json:"location"`
+		VMSize   string `json:"vmSize"`
+	} `json:"compute"`
+}
+
+func detectAzureFacts(f *Facts, root string) {
 	// IMDS should exist on Azure VMs
 	client := &http.Client{
 		Timeout: time.Second,
 	}
-	req, _ := http.NewRequest("GET", AzureIMDSEndpoint+"/instance?api-version=2017-03-01", nil)
+	req, _ := http.NewRequest("GET", AzureIMDSEndpoint+"/instance?api-version=2021-02-01", nil)
 	req.Header.Set("Metadata", "true")
 	resp, err := client.Do(req)
 	if err != nil {
 		// Not on Azure
-		return []string{}
+		return
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		// Not 200 status, might not be on Azure
-		return []string{}
+		return
+	}
+
+	f.Cloud = "azure"
+	var instance imdsInstance
+	if err := json.NewDecoder(resp.Body).Decode(&instance); err != nil {
+		log.Debugf("Fail to decode IMDS instance metadata: %s", err)
+	} else {
+		f.Region = instance.Compute.Location
+		f.VMSize = instance.Compute.VMSize
 	}
 
 	// If we are on Azure, check if it's AKS
-	return append([]string{"azure"}, getAksFlags()...)
+	f.AKS = isAks(root)
 }
 
-func getAksFlags() []string {
-	// Check kubernetes directory to see if it's a AKS node
-	finfo, err := os.Stat("/etc/kubernetes")
-	if err != nil {
-		return []string{}
-	}
-	if !finfo.IsDir() {
-		// Not a dir
-		return []string{}
-	}
-	return []string{"aks"}
+func isAks(root string) bool {
+	// Cloud provider config of AKS nodes
+	return exists(root, "etc/kubernetes/azure.json")
 }
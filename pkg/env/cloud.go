@@ -0,0 +1,38 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const dmiPath = "sys/class/dmi/id"
+
+// detectCloudFacts detects the cloud from DMI first, so that only Azure VMs
+// and hosts without DMI info wait for Azure IMDS.
+func detectCloudFacts(f *Facts, root string) {
+	if cloud, ok := detectCloudFromDMI(root); ok && cloud != "azure" {
+		f.Cloud = cloud
+		return
+	}
+	detectAzureFacts(f, root)
+}
+
+// detectCloudFromDMI returns azure, aws or gcp from DMI info under root, or
+// empty if it's another platform. ok is false if DMI info can't be read.
+func detectCloudFromDMI(root string) (cloud string, ok bool) {
+	if _, err := os.Stat(filepath.Join(root, dmiPath)); err != nil {
+		return "", false
+	}
+	vendor := readFile(root, filepath.Join(dmiPath, "sys_vendor"))
+	product := readFile(root, filepath.Join(dmiPath, "product_name"))
+	switch {
+	case readFile(root, filepath.Join(dmiPath, "chassis_asset_tag")) == azureChassisAssetTag:
+		return "azure", true
+	case strings.HasPrefix(vendor, "Amazon"):
+		return "aws", true
+	case vendor == "Google" || strings.HasPrefix(product, "Google Compute Engine"):
+		return "gcp", true
+	}
+	return "", true
+}
@@ -2,10 +2,10 @@
 
 package env
 
-import "runtime"
+import (
+	"runtime"
+)
 
-func getLinuxFlags() []string {
-	return []string{
-		runtime.GOOS,
-	}
+func detectSystemFacts(f *Facts, root string) {
+	f.OS = runtime.GOOS
 }
@@ -1,15 +1,89 @@
 package env
 
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+)
+
 type Environment interface {
 	HasFlag(flag string) bool
+	GetFlags() []string
+	// GetFacts returns facts of the environment. Unknown facts are empty.
+	GetFacts() *Facts
+}
+
+// Facts describe where kdebug runs. Empty fields are unknown or not applicable.
+type Facts struct {
+	OS            string `json:"os" yaml:"os"`
+	Distro        string `json:"distro,omitempty" yaml:"distro,omitempty"`
+	DistroVersion string `json:"distroVersion,omitempty" yaml:"distroVersion,omitempty"`
+	KernelVersion string `json:"kernelVersion,omitempty" yaml:"kernelVersion,omitempty"`
+	// CgroupVersion is 1 or 2.
+	CgroupVersion int  `json:"cgroupVersion,omitempty" yaml:"cgroupVersion,omitempty"`
+	Root          bool `json:"root" yaml:"root"`
+
+	// InKubernetes is true on a Kubernetes node or in a pod. KubernetesDistro
+	// is aks, k3s, kind or kubeadm.
+	InKubernetes            bool   `json:"inKubernetes" yaml:"inKubernetes"`
+	KubernetesDistro        string `json:"kubernetesDistro,omitempty" yaml:"kubernetesDistro,omitempty"`
+	NodeName                string `json:"nodeName,omitempty" yaml:"nodeName,omitempty"`
+	KubeletVersion          string `json:"kubeletVersion,omitempty" yaml:"kubeletVersion,omitempty"`
+	ContainerRuntime        string `json:"containerRuntime,omitempty" yaml:"containerRuntime,omitempty"`
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion,omitempty" yaml:"containerRuntimeVersion,omitempty"`
+	ClusterDNS              string `json:"clusterDNS,omitempty" yaml:"clusterDNS,omitempty"`
+
+	// Cloud is azure, aws or gcp.
+	Cloud  string `json:"cloud,omitempty" yaml:"cloud,omitempty"`
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	VMSize string `json:"vmSize,omitempty" yaml:"vmSize,omitempty"`
+	AKS    bool   `json:"aks,omitempty" yaml:"aks,omitempty"`
+}
+
+// Flags derives flags checkers require, e.g. linux, ubuntu, root, azure, aks, k8s and k3s.
+func (f *Facts) Flags() []string {
+	flags := []string{}
+	if f.OS != "" {
+		flags = append(flags, f.OS)
+	}
+	if f.Distro != "" {
+		flags = append(flags, f.Distro)
+	}
+	if f.Root {
+		flags = append(flags, "root")
+	}
+	if f.Cloud != "" {
+		flags = append(flags, f.Cloud)
+	}
+	if f.AKS {
+		flags = append(flags, "aks")
+	}
+	if f.InKubernetes {
+		flags = append(flags, "k8s")
+	}
+	if f.KubernetesDistro != "" && !hasFlag(flags, f.KubernetesDistro) {
+		flags = append(flags, f.KubernetesDistro)
+	}
+	return flags
 }
 
 type StaticEnvironment struct {
 	Flags []string
+	Facts Facts
 }
 
 func (e *StaticEnvironment) HasFlag(flag string) bool {
-	for _, f := range e.Flags {
+	return hasFlag(e.Flags, flag)
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
 		if flag == f {
 			return true
 		}
@@ -17,17 +91,182 @@ func (e *StaticEnvironment) HasFlag(flag string) bool {
 	return false
 }
 
+func (e *StaticEnvironment) GetFlags() []string {
+	return e.Flags
+}
+
+func (e *StaticEnvironment) GetFacts() *Facts {
+	return &e.Facts
+}
+
+// GetEnvironment returns the environment detected lazily on first use.
 func GetEnvironment() Environment {
-	return &StaticEnvironment{
-		Flags: getFlags(),
+	return NewEnvironment(Options{})
+}
+
+// Options override detection of the environment.
+type Options struct {
+	// Flags are forced on without detecting them, e.g. azure.
+	Flags []string
+	// NoDetect skips detection of the distro, cloud and Kubernetes, which
+	// reads files, lists processes and queries instance metadata. The OS,
+	// root and forced flags are still set.
+	NoDetect bool
+	// Root is where files of the host are read from, e.g. /host in a pod
+	// mounting the host filesystem. Defaults to DefaultRoot.
+	Root string
+	// KubeClient reads versions of the node from the API server. They are
+	// unknown without it.
+	KubeClient kubernetes.Interface
+}
+
+// Validate rejects unknown flags.
+func (o *Options) Validate() error {
+	known := knownFlags()
+	for _, flag := range o.Flags {
+		if !hasFlag(known, flag) {
+			return fmt.Errorf("Unknown environment flag %q. Expect one of %s", flag, strings.Join(known, ", "))
+		}
 	}
+	return nil
 }
 
-func getFlags() []string {
-	flags := []string{}
-	flags = append(flags, getLinuxFlags()...)
-	flags = append(flags, getAzureFlags()...)
-	flags = append(flags, getK8sFlags()...)
+// DetectedEnvironment detects facts on first use and caches them. Facts are
+// detected in groups so that checking the linux flag doesn't probe IMDS or
+// list processes. It's safe for concurrent use.
+type DetectedEnvironment struct {
+	options    Options
+	system     detection
+	cloud      detection
+	kubernetes detection
+}
 
-	return flags
+type detection struct {
+	once   sync.Once
+	detect func(*Facts)
+	facts  Facts
+}
+
+func (d *detection) get(noDetect bool) *Facts {
+	d.once.Do(func() {
+		if !noDetect {
+			d.detect(&d.facts)
+			log.WithFields(log.Fields{"facts": d.facts}).Debug("Detected environment")
+		}
+	})
+	return &d.facts
+}
+
+func NewEnvironment(opts Options) *DetectedEnvironment {
+	root := opts.Root
+	if root == "" {
+		root = DefaultRoot
+	}
+	return &DetectedEnvironment{
+		options:    opts,
+		system:     detection{detect: func(f *Facts) { detectSystemFacts(f, root) }},
+		cloud:      detection{detect: func(f *Facts) { detectCloudFacts(f, root) }},
+		kubernetes: detection{detect: func(f *Facts) { detectKubernetesFacts(f, root, opts.KubeClient) }},
+	}
+}
+
+func (e *DetectedEnvironment) HasFlag(flag string) bool {
+	forced := basicFacts()
+	forced.applyFlags(e.options.Flags)
+	if hasFlag(forced.Flags(), flag) {
+		return true
+	}
+
+	// Only detect facts the flag derives from
+	var facts *Facts
+	switch flag {
+	case "azure", "aks", "aws", "gcp":
+		facts = e.cloud.get(e.options.NoDetect)
+	case "k8s", "k3s", "kind", "kubeadm":
+		facts = e.kubernetes.get(e.options.NoDetect)
+	default:
+		facts = e.system.get(e.options.NoDetect)
+	}
+	return hasFlag(facts.Flags(), flag)
+}
+
+func (e *DetectedEnvironment) GetFlags() []string {
+	return e.GetFacts().Flags()
+}
+
+// GetFacts detects all facts. Forced flags are reflected in the facts, e.g.
+// the azure flag sets the cloud.
+func (e *DetectedEnvironment) GetFacts() *Facts {
+	system := e.system.get(e.options.NoDetect)
+	cloud := e.cloud.get(e.options.NoDetect)
+	kubernetes := e.kubernetes.get(e.options.NoDetect)
+
+	facts := *system
+	facts.Cloud, facts.Region, facts.VMSize, facts.AKS = cloud.Cloud, cloud.Region, cloud.VMSize, cloud.AKS
+	facts.InKubernetes = kubernetes.InKubernetes
+	facts.KubernetesDistro = kubernetes.KubernetesDistro
+	facts.NodeName = kubernetes.NodeName
+	facts.KubeletVersion = kubernetes.KubeletVersion
+	facts.ContainerRuntime = kubernetes.ContainerRuntime
+	facts.ContainerRuntimeVersion = kubernetes.ContainerRuntimeVersion
+	facts.ClusterDNS = kubernetes.ClusterDNS
+	basic := basicFacts()
+	facts.OS, facts.Root = basic.OS, basic.Root
+	facts.applyFlags(e.options.Flags)
+	return &facts
+}
+
+// basicFacts returns facts that are known without detection.
+func basicFacts() *Facts {
+	return &Facts{OS: runtime.GOOS, Root: os.Geteuid() == 0}
+}
+
+var (
+	osFlags    = []string{"linux", "windows", "darwin"}
+	cloudFlags = []string{"azure", "aws", "gcp"}
+	// distroFlags are os-release IDs of well-known distros.
+	distroFlags = []string{"ubuntu", "debian", "azurelinux", "flatcar", "rhel", "centos", "fedora", "rocky", "almalinux", "amzn", "sles", "alpine"}
+)
+
+// knownFlags returns flags that can be forced.
+func knownFlags() []string {
+	flags := append([]string{}, osFlags...)
+	flags = append(flags, "root")
+	flags = append(flags, cloudFlags...)
+	flags = append(flags, "k8s")
+	for _, distro := range kubernetesDistros {
+		flags = append(flags, distro.name)
+	}
+	flags = append(flags, distroFlags...)
+	aliases := make([]string, 0, len(distroAliases))
+	for alias := range distroAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return append(flags, aliases...)
+}
+
+// applyFlags sets facts of forced flags. Unknown flags are ignored since
+// they are rejected by Options.Validate.
+func (f *Facts) applyFlags(flags []string) {
+	for _, flag := range flags {
+		switch {
+		case hasFlag(osFlags, flag):
+			f.OS = flag
+		case flag == "root":
+			f.Root = true
+		case hasFlag(cloudFlags, flag):
+			f.Cloud = flag
+		case flag == "aks":
+			f.Cloud, f.AKS = "azure", true
+		case flag == "k8s":
+			f.InKubernetes = true
+		case flag == "k3s", flag == "kind", flag == "kubeadm":
+			f.InKubernetes, f.KubernetesDistro = true, flag
+		case hasFlag(distroFlags, flag):
+			f.Distro = flag
+		case distroAliases[flag] != "":
+			f.Distro = distroAliases[flag]
+		}
+	}
 }
@@ -0,0 +1,135 @@
+package env
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFactsFlags(t *testing.T) {
+	facts := &Facts{
+		OS:           "linux",
+		Distro:       "ubuntu",
+		Root:         true,
+		Cloud:        "azure",
+		AKS:          true,
+		InKubernetes: true,
+	}
+	expected := []string{"linux", "ubuntu", "root", "azure", "aks", "k8s"}
+	if flags := facts.Flags(); !reflect.DeepEqual(flags, expected) {
+		t.Errorf("Expect flags %v but got %v", expected, flags)
+	}
+	if flags := (&Facts{}).Flags(); len(flags) != 0 {
+		t.Errorf("Expect no flags but got %v", flags)
+	}
+}
+
+func newTestEnvironment(opts Options, system, azure, kubernetes Facts) (*DetectedEnvironment, map[string]int) {
+	calls := map[string]int{}
+	e := NewEnvironment(opts)
+	e.system.detect = func(f *Facts) { calls["system"]++; *f = system }
+	e.cloud.detect = func(f *Facts) { calls["cloud"]++; *f = azure }
+	e.kubernetes.detect = func(f *Facts) { calls["kubernetes"]++; *f = kubernetes }
+	return e, calls
+}
+
+func TestDetectedEnvironmentLazy(t *testing.T) {
+	e, calls := newTestEnvironment(Options{},
+		Facts{OS: "linux", Distro: "ubuntu"},
+		Facts{Cloud: "azure", Region: "eastus"},
+		Facts{InKubernetes: true, NodeName: "node-0"})
+
+	if !e.HasFlag("linux") || !e.HasFlag("ubuntu") || e.HasFlag("root") != (os.Geteuid() == 0) {
+		t.Errorf("Unexpected system flags")
+	}
+	if calls["system"] != 1 || calls["cloud"] != 0 || calls["kubernetes"] != 0 {
+		t.Errorf("Expect only system facts detected once but got %v", calls)
+	}
+
+	facts := e.GetFacts()
+	if facts.Distro != "ubuntu" || facts.Region != "eastus" || facts.NodeName != "node-0" {
+		t.Errorf("Expect merged facts but got %+v", facts)
+	}
+	e.GetFacts()
+	if calls["system"] != 1 || calls["cloud"] != 1 || calls["kubernetes"] != 1 {
+		t.Errorf("Expect each group detected once but got %v", calls)
+	}
+}
+
+func TestDetectedEnvironmentOverrides(t *testing.T) {
+	e, calls := newTestEnvironment(Options{Flags: []string{"aks"}}, Facts{OS: "linux"}, Facts{}, Facts{})
+	if !e.HasFlag("azure") || !e.HasFlag("aks") {
+		t.Errorf("Expect forced flags")
+	}
+	if calls["cloud"] != 0 {
+		t.Errorf("Expect no cloud detection with forced flags but got %v", calls)
+	}
+
+	e, calls = newTestEnvironment(Options{Flags: []string{"k8s"}, NoDetect: true}, Facts{OS: "linux", Distro: "ubuntu"}, Facts{}, Facts{})
+	if e.HasFlag("ubuntu") || !e.HasFlag(runtime.GOOS) || e.HasFlag("root") != (os.Geteuid() == 0) {
+		t.Errorf("Expect only OS, root and forced flags with no detect")
+	}
+	expected := []string{runtime.GOOS, "k8s"}
+	if os.Geteuid() == 0 {
+		expected = []string{runtime.GOOS, "root", "k8s"}
+	}
+	if flags := e.GetFlags(); !reflect.DeepEqual(flags, expected) {
+		t.Errorf("Expect flags %v but got %v", expected, flags)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expect no detection but got %v", calls)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	opts := &Options{Flags: []string{"linux", "root", "aks", "k3s", "ubuntu", "mariner"}}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Expect no error but got %s", err)
+	}
+	opts = &Options{Flags: []string{"ubuntu", "azrue"}}
+	if err := opts.Validate(); err == nil {
+		t.Errorf("Expect error of unknown flag")
+	}
+
+	f := &Facts{}
+	f.applyFlags([]string{"mariner"})
+	if f.Distro != "azurelinux" {
+		t.Errorf("Expect distro azurelinux of alias but got %q", f.Distro)
+	}
+}
+
+func TestDetectedEnvironmentRoot(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("System facts are read from files on Linux only")
+	}
+	t.Setenv(KubernetesServiceHost, "10.0.0.1")
+	t.Setenv(NodeNameEnv, "node-0")
+	root := fakeRoot(t, map[string]string{
+		"etc/os-release":                    "ID=ubuntu\nVERSION_ID=\"22.04\"\n",
+		"proc/sys/kernel/osrelease":         "5.15.0-1049-azure\n",
+		"sys/fs/cgroup/cgroup.controllers":  "cpu memory\n",
+		"sys/class/dmi/id/sys_vendor":       "Amazon EC2\n",
+		"etc/rancher/k3s/k3s.yaml":          "",
+		"etc/resolv.conf":                   "nameserver 10.43.0.10\n",
+		"var/lib/kubelet/kubeadm-flags.env": "",
+	})
+
+	facts := NewEnvironment(Options{Root: root}).GetFacts()
+	expected := Facts{
+		OS:               "linux",
+		Distro:           "ubuntu",
+		DistroVersion:    "22.04",
+		KernelVersion:    "5.15.0-1049-azure",
+		CgroupVersion:    2,
+		Root:             os.Geteuid() == 0,
+		InKubernetes:     true,
+		KubernetesDistro: "k3s",
+		NodeName:         "node-0",
+		ClusterDNS:       "10.43.0.10",
+		Cloud:            "aws",
+	}
+	if !reflect.DeepEqual(*facts, expected) {
+		t.Errorf("Expect facts %+v but got %+v", expected, *facts)
+	}
+}
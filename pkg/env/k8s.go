@@ -1,47 +1,187 @@
 package env
 
 import (
-	"fmt"
+	"bufio"
+	"context"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/shirou/gopsutil/v3/process"
 	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
-const KubernetesServiceHost = "KUBERNETES_SERVICE_HOST"
+const (
+	KubernetesServiceHost = "KUBERNETES_SERVICE_HOST"
+	// NodeNameEnv is usually set to spec.nodeName with the downward API.
+	NodeNameEnv = "NODE_NAME"
+	// Paths of the host are read under Options.Root.
+	ResolvConfPath    = "/etc/resolv.conf"
+	KubeletConfigPath = "/var/lib/kubelet/config.yaml"
+	// nodeTimeout limits the time to get the node from the API server.
+	nodeTimeout = 5 * time.Second
+)
 
-func getK8sFlags() []string {
-	var flags []string
-	if inK8s() {
-		flags = append(flags, "k8s")
-	}
-	return flags
+var containerRuntimes = []string{"containerd", "dockerd", "crio"}
+
+// kubernetesDistros are well-known files of Kubernetes distributions, in the
+// order of detection. kind nodes are set up by kubeadm too.
+var kubernetesDistros = []struct {
+	name  string
+	paths []string
+}{
+	{"aks", []string{"etc/kubernetes/azure.json"}},
+	{"k3s", []string{"etc/rancher/k3s", "var/lib/rancher/k3s"}},
+	{"kind", []string{"kind/version"}},
+	{"kubeadm", []string{"var/lib/kubelet/kubeadm-flags.env", "etc/kubernetes/admin.conf"}},
 }
 
-func inK8s() bool {
-	//check if in a pod
-	for _, e := range os.Environ() {
-		if strings.Contains(e, KubernetesServiceHost) {
-			return true
+// detectKubernetesDistro returns the Kubernetes distribution of the node under root, or empty if it's unknown.
+func detectKubernetesDistro(root string) string {
+	for _, distro := range kubernetesDistros {
+		for _, path := range distro.paths {
+			if exists(root, path) {
+				return distro.name
+			}
 		}
 	}
-	// check in a host vm
+	return ""
+}
+
+func detectKubernetesFacts(f *Facts, root string, client kubernetes.Interface) {
+	// k3s runs kubelet in the k3s process
+	if f.KubernetesDistro = detectKubernetesDistro(root); f.KubernetesDistro != "" {
+		f.InKubernetes = true
+	}
+
+	// Check if in a pod. Nameserver of a pod is the cluster DNS.
+	if os.Getenv(KubernetesServiceHost) != "" {
+		f.InKubernetes = true
+		f.NodeName = os.Getenv(NodeNameEnv)
+		f.ClusterDNS = getNameserver(filepath.Join(root, ResolvConfPath))
+	}
+
+	detectProcessFacts(f, root)
+	if client != nil && f.NodeName != "" {
+		detectNodeFacts(f, client)
+	}
+}
+
+// detectProcessFacts checks kubelet and container runtime processes of a host vm.
+func detectProcessFacts(f *Facts, root string) {
 	processes, err := process.Processes()
 	if err != nil {
-		log.Warn(fmt.Sprintf("List process error %v\n", err))
-		return false
-	} else {
-		for _, proc := range processes {
-			name, err := proc.Name()
-			if err != nil {
-				log.Warn(fmt.Sprintf("List process error %v. Skip in-cluster tcp checking\n", err))
-				return false
-			}
-			if name == "kubelet" {
-				return true
+		log.Warnf("List process error %v", err)
+		return
+	}
+	for _, proc := range processes {
+		name, err := proc.Name()
+		if err != nil {
+			continue
+		}
+		if name == "kubelet" {
+			f.InKubernetes = true
+			detectKubeletFacts(f, proc, root)
+		}
+		for _, runtime := range containerRuntimes {
+			if name == runtime && f.ContainerRuntime == "" {
+				f.ContainerRuntime = runtime
 			}
 		}
 	}
-	return false
+}
+
+func detectKubeletFacts(f *Facts, proc *process.Process, root string) {
+	args, _ := proc.CmdlineSlice()
+
+	if f.NodeName == "" {
+		f.NodeName = getFlagValue(args, "hostname-override")
+	}
+	if f.NodeName == "" {
+		if hostname, err := os.Hostname(); err == nil {
+			f.NodeName = strings.ToLower(hostname)
+		}
+	}
+
+	if dns := getFlagValue(args, "cluster-dns"); dns != "" {
+		f.ClusterDNS = strings.Split(dns, ",")[0]
+		return
+	}
+	configPath := getFlagValue(args, "config")
+	if configPath == "" {
+		configPath = KubeletConfigPath
+	}
+	if dns := getKubeletConfigClusterDNS(filepath.Join(root, configPath)); dns != "" {
+		f.ClusterDNS = dns
+	}
+}
+
+// detectNodeFacts reads versions of kubelet and the container runtime from
+// the node status, which kubelet reports to the API server.
+func detectNodeFacts(f *Facts, client kubernetes.Interface) {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeTimeout)
+	defer cancel()
+	node, err := client.CoreV1().Nodes().Get(ctx, f.NodeName, metav1.GetOptions{})
+	if err != nil {
+		log.Debugf("Fail to get node %s: %s", f.NodeName, err)
+		return
+	}
+	info := node.Status.NodeInfo
+	f.KubeletVersion = info.KubeletVersion
+	// e.g. containerd://1.7.1
+	runtime, version := "", info.ContainerRuntimeVersion
+	if i := strings.Index(version, "://"); i >= 0 {
+		runtime, version = version[:i], version[i+3:]
+	}
+	if f.ContainerRuntime == "" {
+		f.ContainerRuntime = runtime
+	}
+	f.ContainerRuntimeVersion = version
+}
+
+// getFlagValue returns value of --name=value or --name value in args.
+func getFlagValue(args []string, name string) string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "--"+name+"=") {
+			return strings.TrimPrefix(arg, "--"+name+"=")
+		}
+		if arg == "--"+name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func getKubeletConfigClusterDNS(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var config struct {
+		ClusterDNS []string `yaml:"clusterDNS"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil || len(config.ClusterDNS) == 0 {
+		return ""
+	}
+	return config.ClusterDNS[0]
+}
+
+func getNameserver(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return fields[1]
+		}
+	}
+	return ""
 }
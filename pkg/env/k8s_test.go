@@ -0,0 +1,87 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetFlagValue(t *testing.T) {
+	args := []string{"/usr/bin/kubelet", "--cluster-dns=10.0.0.10", "--config", "/etc/kubelet.yaml", "--v"}
+	if v := getFlagValue(args, "cluster-dns"); v != "10.0.0.10" {
+		t.Errorf("Expect 10.0.0.10 but got %q", v)
+	}
+	if v := getFlagValue(args, "config"); v != "/etc/kubelet.yaml" {
+		t.Errorf("Expect /etc/kubelet.yaml but got %q", v)
+	}
+	if v := getFlagValue(args, "v"); v != "" {
+		t.Errorf("Expect empty value but got %q", v)
+	}
+}
+
+func TestGetKubeletConfigClusterDNS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("kind: KubeletConfiguration\nclusterDNS:\n  - 10.0.0.10\n  - 10.0.0.11\n"), 0644)
+	if dns := getKubeletConfigClusterDNS(path); dns != "10.0.0.10" {
+		t.Errorf("Expect 10.0.0.10 but got %q", dns)
+	}
+	if dns := getKubeletConfigClusterDNS(filepath.Join(t.TempDir(), "missing.yaml")); dns != "" {
+		t.Errorf("Expect no cluster DNS but got %q", dns)
+	}
+}
+
+func TestGetNameserver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	os.WriteFile(path, []byte("search default.svc.cluster.local\nnameserver 10.0.0.10\noptions ndots:5\n"), 0644)
+	if ns := getNameserver(path); ns != "10.0.0.10" {
+		t.Errorf("Expect 10.0.0.10 but got %q", ns)
+	}
+}
+
+func TestDetectKubernetesDistro(t *testing.T) {
+	tests := []struct {
+		files  map[string]string
+		distro string
+	}{
+		{map[string]string{"etc/kubernetes/azure.json": "{}"}, "aks"},
+		{map[string]string{"etc/rancher/k3s/k3s.yaml": ""}, "k3s"},
+		{map[string]string{"kind/version": "v1.27.3", "var/lib/kubelet/kubeadm-flags.env": ""}, "kind"},
+		{map[string]string{"var/lib/kubelet/kubeadm-flags.env": ""}, "kubeadm"},
+		{map[string]string{}, ""},
+	}
+	for _, test := range tests {
+		if distro := detectKubernetesDistro(fakeRoot(t, test.files)); distro != test.distro {
+			t.Errorf("Expect distro %q but got %q", test.distro, distro)
+		}
+	}
+	if !isAks(fakeRoot(t, map[string]string{"etc/kubernetes/azure.json": "{}"})) {
+		t.Errorf("Expect AKS node")
+	}
+}
+
+func TestDetectNodeFacts(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-0"},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{
+				KubeletVersion:          "v1.27.3",
+				ContainerRuntimeVersion: "containerd://1.7.1",
+			},
+		},
+	})
+	f := &Facts{NodeName: "node-0"}
+	detectNodeFacts(f, client)
+	if f.KubeletVersion != "v1.27.3" || f.ContainerRuntime != "containerd" || f.ContainerRuntimeVersion != "1.7.1" {
+		t.Errorf("Unexpected versions of node: %+v", f)
+	}
+
+	f = &Facts{NodeName: "nosuchnode"}
+	detectNodeFacts(f, client)
+	if f.KubeletVersion != "" || f.ContainerRuntimeVersion != "" {
+		t.Errorf("Expect unknown versions of missing node but got %+v", f)
+	}
+}
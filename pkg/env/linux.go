@@ -3,24 +3,22 @@
 package env
 
 import (
-	"os"
 	"runtime"
-	"strings"
-
-	"github.com/zcalusic/sysinfo"
 )
 
-func getLinuxFlags() []string {
-	var si sysinfo.SysInfo
-	si.GetSysInfo()
-	flags := []string{
-		runtime.GOOS,
-		strings.ToLower(si.OS.Vendor),
-	}
+func detectSystemFacts(f *Facts, root string) {
+	f.OS = runtime.GOOS
+	f.Distro, f.DistroVersion = detectDistro(root)
+	f.KernelVersion = readFile(root, "proc/sys/kernel/osrelease")
+	f.CgroupVersion = getCgroupVersion(root)
+}
 
-	if os.Geteuid() == 0 {
-		flags = append(flags, "root")
+func getCgroupVersion(root string) int {
+	if exists(root, "sys/fs/cgroup/cgroup.controllers") {
+		return 2
 	}
-
-	return flags
+	if exists(root, "sys/fs/cgroup") {
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,72 @@
+package env
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultRoot is where files of the host are read from unless Options.Root is set.
+const DefaultRoot = "/"
+
+var osReleasePaths = []string{"etc/os-release", "usr/lib/os-release"}
+
+// distroAliases maps os-release IDs to distro flags, e.g. Mariner is renamed to Azure Linux.
+var distroAliases = map[string]string{
+	"mariner": "azurelinux",
+}
+
+// detectDistro returns ID and VERSION_ID of os-release under root, e.g.
+// ubuntu, debian, azurelinux, flatcar or rhel.
+func detectDistro(root string) (string, string) {
+	for _, path := range osReleasePaths {
+		release := readOSRelease(filepath.Join(root, path))
+		if release == nil {
+			continue
+		}
+		distro := strings.ToLower(release["ID"])
+		if alias, ok := distroAliases[distro]; ok {
+			distro = alias
+		}
+		return distro, release["VERSION_ID"]
+	}
+	return "", ""
+}
+
+func readOSRelease(path string) map[string]string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	release := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		release[parts[0]] = strings.Trim(parts[1], `"'`)
+	}
+	return release
+}
+
+// exists returns whether the path under root exists.
+func exists(root, path string) bool {
+	_, err := os.Stat(filepath.Join(root, path))
+	return err == nil
+}
+
+// readFile returns trimmed content of the file under root, or empty if it can't be read.
+func readFile(root, path string) string {
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
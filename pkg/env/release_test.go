@@ -0,0 +1,63 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeRoot creates a root filesystem with the files.
+func fakeRoot(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestDetectDistro(t *testing.T) {
+	tests := []struct {
+		files   map[string]string
+		distro  string
+		version string
+	}{
+		{map[string]string{"etc/os-release": "NAME=\"Ubuntu\"\nID=ubuntu\nVERSION_ID=\"22.04\"\n"}, "ubuntu", "22.04"},
+		{map[string]string{"etc/os-release": "NAME=\"CBL-Mariner\"\nID=mariner\nVERSION_ID=\"2.0\"\n"}, "azurelinux", "2.0"},
+		{map[string]string{"etc/os-release": "NAME=\"Microsoft Azure Linux\"\nID=azurelinux\nVERSION_ID=\"3.0\"\n"}, "azurelinux", "3.0"},
+		{map[string]string{"usr/lib/os-release": "NAME=\"Flatcar Container Linux by Kinvolk\"\nID=flatcar\nVERSION_ID=3510.2.0\n"}, "flatcar", "3510.2.0"},
+		{map[string]string{"etc/os-release": "# RHEL\nID=\"rhel\"\nID_LIKE=\"fedora\"\nVERSION_ID=\"9.2\"\n"}, "rhel", "9.2"},
+		{map[string]string{}, "", ""},
+	}
+	for _, test := range tests {
+		distro, version := detectDistro(fakeRoot(t, test.files))
+		if distro != test.distro || version != test.version {
+			t.Errorf("Expect %s %s but got %s %s", test.distro, test.version, distro, version)
+		}
+	}
+}
+
+func TestDetectCloudFromDMI(t *testing.T) {
+	tests := []struct {
+		files map[string]string
+		cloud string
+		ok    bool
+	}{
+		{map[string]string{"sys/class/dmi/id/chassis_asset_tag": azureChassisAssetTag + "\n"}, "azure", true},
+		{map[string]string{"sys/class/dmi/id/sys_vendor": "Amazon EC2\n"}, "aws", true},
+		{map[string]string{"sys/class/dmi/id/product_name": "Google Compute Engine\n"}, "gcp", true},
+		{map[string]string{"sys/class/dmi/id/sys_vendor": "QEMU\n"}, "", true},
+		{map[string]string{}, "", false},
+	}
+	for _, test := range tests {
+		cloud, ok := detectCloudFromDMI(fakeRoot(t, test.files))
+		if cloud != test.cloud || ok != test.ok {
+			t.Errorf("Expect cloud %q, %v but got %q, %v", test.cloud, test.ok, cloud, ok)
+		}
+	}
+}
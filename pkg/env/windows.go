@@ -6,8 +6,6 @@ import (
 	"runtime"
 )
 
-func getLinuxFlags() []string {
-	return []string{
-		runtime.GOOS,
-	}
+func detectSystemFacts(f *Facts, root string) {
+	f.OS = runtime.GOOS
 }
@@ -1,13 +1,58 @@
 package formatters
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/Azure/kdebug/pkg/base"
 	"github.com/Azure/kdebug/pkg/batch"
+	"github.com/fatih/color"
 )
 
 type Formatter interface {
 	WriteResults(io.Writer, []*base.CheckResult) error
-	WriteBatchResults(io.Writer, []*batch.BatchResult) error
+	WriteBatchResults(io.Writer, *batch.BatchRun) error
+}
+
+// ReportFormatter writes results with metadata of the run, e.g. its
+// environment and times. Runners prefer it over WriteResults.
+type ReportFormatter interface {
+	WriteReport(io.Writer, *base.Report) error
+}
+
+func colorStatus(s base.Status) string {
+	switch s {
+	case base.StatusPass:
+		return color.GreenString("%s", s)
+	case base.StatusWarn:
+		return color.YellowString("%s", s)
+	case base.StatusFail, base.StatusErrored:
+		return color.RedString("%s", s)
+	default:
+		return string(s)
+	}
+}
+
+// machineName names a machine of batch results, or a cluster in runs on clusters.
+func machineName(m *batch.MachineReport) string {
+	if m.Cluster != "" {
+		return m.Cluster
+	}
+	return m.Machine
+}
+
+func formatSkipped(s base.Summary) string {
+	if s.Skipped == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d skipped)", s.Skipped)
+}
+
+func formatSummary(s base.Summary) string {
+	return fmt.Sprintf("%v checks passed. %v warnings. %v failed. %v errored.%s",
+		color.GreenString("%d", s.Pass),
+		color.YellowString("%d", s.Warn),
+		color.RedString("%d", s.Fail),
+		color.RedString("%d", s.Errored),
+		formatSkipped(s))
 }
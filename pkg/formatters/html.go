@@ -0,0 +1,176 @@
+package formatters
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/batch"
+)
+
+//go:embed html.tmpl
+var htmlTemplateText string
+
+var htmlTemplate = template.Must(template.New("html").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(htmlTemplateText))
+
+// statusRanks orders statuses from the least to the most severe, e.g. a
+// machine with a failed and a passed result is failed.
+var statusRanks = map[base.Status]int{
+	base.StatusSkipped: 1,
+	base.StatusPass:    2,
+	base.StatusWarn:    3,
+	base.StatusErrored: 4,
+	base.StatusFail:    5,
+}
+
+// HtmlFormatter writes a single offline HTML page. In batch mode it's a
+// matrix of machines and checkers that can be filtered by status.
+type HtmlFormatter struct{}
+
+type htmlPage struct {
+	Title     string
+	StartTime string
+	EndTime   string
+	Executor  string
+	Revision  string
+	Summary   batch.ReportSummary
+	Statuses  []base.Status
+	Checkers  []string
+	Machines  []*htmlMachine
+}
+
+type htmlMachine struct {
+	ID       string
+	Name     string
+	Hostname string
+	Error    string
+	Status   base.Status
+	// Statuses are all statuses of results of the machine for filtering.
+	Statuses []string
+	Cells    []*htmlCell
+	Results  []*htmlResult
+}
+
+// htmlCell is results of a checker on a machine. Status is empty if the checker didn't run.
+type htmlCell struct {
+	Status base.Status
+	Count  int
+}
+
+type htmlResult struct {
+	*base.CheckResult
+	Status   base.Status
+	Severity base.Severity
+}
+
+func (f *HtmlFormatter) WriteResults(w io.Writer, results []*base.CheckResult) error {
+	return f.WriteReport(w, base.NewReport(results))
+}
+
+func (f *HtmlFormatter) WriteReport(w io.Writer, report *base.Report) error {
+	hostname := report.Hostname
+	if hostname == "" {
+		hostname = "localhost"
+	}
+	run := &batch.BatchRun{
+		StartTime: report.StartTime,
+		EndTime:   report.EndTime,
+		Results: []*batch.BatchResult{
+			{Machine: hostname, CheckResults: report.Results, Report: report},
+		},
+	}
+	page := newHtmlPage(run)
+	page.Title = "kdebug report of " + hostname
+	page.Revision = report.Revision
+	return htmlTemplate.Execute(w, page)
+}
+
+func (f *HtmlFormatter) WriteBatchResults(w io.Writer, run *batch.BatchRun) error {
+	page := newHtmlPage(run)
+	page.Title = fmt.Sprintf("kdebug report of %d machines", page.Summary.Machines)
+	return htmlTemplate.Execute(w, page)
+}
+
+func newHtmlPage(run *batch.BatchRun) *htmlPage {
+	report := batch.NewReport(run)
+	page := &htmlPage{
+		StartTime: formatHtmlTime(report.StartTime),
+		EndTime:   formatHtmlTime(report.EndTime),
+		Executor:  report.Executor,
+		Revision:  report.Revision,
+		Summary:   report.Summary,
+		Statuses: []base.Status{
+			base.StatusFail, base.StatusErrored, base.StatusWarn, base.StatusPass, base.StatusSkipped,
+		},
+	}
+
+	// Columns of checkers of all machines, sorted by name
+	checkers := map[string]int{}
+	for _, m := range report.Machines {
+		for _, r := range m.Results {
+			if _, ok := checkers[r.Checker]; !ok {
+				checkers[r.Checker] = 0
+				page.Checkers = append(page.Checkers, r.Checker)
+			}
+		}
+	}
+	sort.Strings(page.Checkers)
+	for i, c := range page.Checkers {
+		checkers[c] = i
+	}
+
+	for i, m := range report.Machines {
+		machine := &htmlMachine{
+			ID:       fmt.Sprintf("machine-%d", i),
+			Name:     machineName(m),
+			Hostname: m.Hostname,
+			Error:    m.Error,
+			Cells:    make([]*htmlCell, len(page.Checkers)),
+		}
+		for j := range machine.Cells {
+			machine.Cells[j] = &htmlCell{}
+		}
+		statuses := map[base.Status]bool{}
+		if m.Error != "" {
+			machine.Status = base.StatusErrored
+			statuses[base.StatusErrored] = true
+		}
+		for _, r := range m.Results {
+			status := r.GetStatus()
+			machine.Results = append(machine.Results, &htmlResult{CheckResult: r, Status: status, Severity: r.GetSeverity()})
+			cell := machine.Cells[checkers[r.Checker]]
+			cell.Count++
+			cell.Status = worseStatus(cell.Status, status)
+			machine.Status = worseStatus(machine.Status, status)
+			statuses[status] = true
+		}
+		for _, s := range page.Statuses {
+			if statuses[s] {
+				machine.Statuses = append(machine.Statuses, string(s))
+			}
+		}
+		page.Machines = append(page.Machines, machine)
+	}
+	return page
+}
+
+func worseStatus(a, b base.Status) base.Status {
+	if statusRanks[b] > statusRanks[a] {
+		return b
+	}
+	return a
+}
+
+func formatHtmlTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,64 @@
+package formatters
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/batch"
+)
+
+func TestHtmlWriteBatchResults(t *testing.T) {
+	run := &batch.BatchRun{
+		Executor: "ssh",
+		Results: []*batch.BatchResult{
+			{
+				Machine: "node-1",
+				CheckResults: []*base.CheckResult{
+					{Checker: "Dns"},
+					{Checker: "OOM", Error: "Process <app> killed", Logs: []string{"oom-kill"}, HelpLinks: []string{"https://example.com/oom"}},
+				},
+			},
+			{Machine: "node-0", Error: errors.New("unreachable")},
+		},
+	}
+	page := newHtmlPage(run)
+	if strings.Join(page.Checkers, ",") != "Dns,OOM" {
+		t.Errorf("Unexpected checkers: %v", page.Checkers)
+	}
+	node1 := page.Machines[1]
+	if node1.Status != base.StatusFail || node1.Cells[0].Status != base.StatusPass || node1.Cells[1].Status != base.StatusFail {
+		t.Errorf("Unexpected matrix row: %+v", node1)
+	}
+	if page.Machines[0].Status != base.StatusErrored || page.Machines[0].Cells[0].Status != "" {
+		t.Errorf("Expect errored machine without results but got %+v", page.Machines[0])
+	}
+
+	var out bytes.Buffer
+	if err := (&HtmlFormatter{}).WriteBatchResults(&out, run); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	html := out.String()
+	for _, s := range []string{"kdebug report of 2 machines", "Process &lt;app&gt; killed", `href="https://example.com/oom"`, `data-statuses="fail pass"`} {
+		if !strings.Contains(html, s) {
+			t.Errorf("Expect %s in HTML", s)
+		}
+	}
+	if strings.Contains(html, "<link") || strings.Contains(html, "<script src") {
+		t.Errorf("Expect self-contained HTML")
+	}
+}
+
+func TestHtmlWriteResults(t *testing.T) {
+	var out bytes.Buffer
+	report := base.NewReport([]*base.CheckResult{{Checker: "Dns"}})
+	report.Hostname = "vm-0"
+	if err := (&HtmlFormatter{}).WriteReport(&out, report); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if !strings.Contains(out.String(), "kdebug report of vm-0") {
+		t.Errorf("Unexpected HTML:\n%s", out.String())
+	}
+}
@@ -11,12 +11,17 @@ import (
 type JsonFormatter struct{}
 
 func (f *JsonFormatter) WriteResults(w io.Writer, results []*base.CheckResult) error {
+	return f.WriteReport(w, base.NewReport(results))
+}
+
+func (f *JsonFormatter) WriteReport(w io.Writer, report *base.Report) error {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "    ")
-	return enc.Encode(results)
+	return enc.Encode(report)
 }
 
-func (f *JsonFormatter) WriteBatchResults(w io.Writer, results []*batch.BatchResult) error {
-	// TODO
-	return nil
+func (f *JsonFormatter) WriteBatchResults(w io.Writer, run *batch.BatchRun) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(batch.NewReport(run))
 }
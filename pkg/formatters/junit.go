@@ -0,0 +1,215 @@
+package formatters
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/batch"
+)
+
+// JUnitFormatter writes results as JUnit XML for CI systems. Each checker is
+// a test suite and each result a test case. In batch mode each machine is a
+// test suite instead. Test cases are named <checker>#<n> by the order of
+// results of the checker, since descriptions may carry changing values. The
+// description goes to the output. Warnings pass with their details in the output.
+type JUnitFormatter struct{}
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Skipped  int               `xml:"skipped,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	Hostname  string           `xml:"hostname,attr,omitempty"`
+	Cases     []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func (f *JUnitFormatter) WriteResults(w io.Writer, results []*base.CheckResult) error {
+	return f.WriteReport(w, base.NewReport(results))
+}
+
+func (f *JUnitFormatter) WriteReport(w io.Writer, report *base.Report) error {
+	suites := &junitTestSuites{Name: "kdebug"}
+	var suite *junitTestSuite
+	names := junitCaseNames{}
+	for _, r := range report.Results {
+		// Results of a checker are next to each other
+		if suite == nil || suite.Name != r.Checker {
+			suite = &junitTestSuite{
+				Name:      r.Checker,
+				Timestamp: formatJUnitTimestamp(report.StartTime),
+				Hostname:  report.Hostname,
+				Time:      formatJUnitTime(r.Duration),
+			}
+			suites.Suites = append(suites.Suites, suite)
+		}
+		suite.add(newJUnitTestCase(r, names.next(r.Checker), r.Checker))
+	}
+	suites.Time = formatJUnitTime(report.EndTime.Sub(report.StartTime))
+	return writeJUnit(w, suites)
+}
+
+func (f *JUnitFormatter) WriteBatchResults(w io.Writer, run *batch.BatchRun) error {
+	suites := &junitTestSuites{
+		Name: "kdebug",
+		Time: formatJUnitTime(run.EndTime.Sub(run.StartTime)),
+	}
+	for _, m := range batch.NewReport(run).Machines {
+		name := machineName(m)
+		suite := &junitTestSuite{
+			Name:     name,
+			Hostname: m.Hostname,
+			Time:     "0.000",
+		}
+		if m.StartTime != nil && m.EndTime != nil {
+			suite.Timestamp = formatJUnitTimestamp(*m.StartTime)
+			suite.Time = formatJUnitTime(m.EndTime.Sub(*m.StartTime))
+		}
+		if m.Error != "" {
+			suite.add(&junitTestCase{
+				Name:      "Run kdebug",
+				Classname: name,
+				Time:      "0.000",
+				Error:     &junitMessage{Message: m.Error, Type: string(base.StatusErrored)},
+			})
+		}
+		names := junitCaseNames{}
+		for _, r := range m.Results {
+			suite.add(newJUnitTestCase(r, names.next(r.Checker), name+"."+r.Checker))
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+	return writeJUnit(w, suites)
+}
+
+func (s *junitTestSuite) add(c *junitTestCase) {
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	switch {
+	case c.Failure != nil:
+		s.Failures++
+	case c.Error != nil:
+		s.Errors++
+	case c.Skipped != nil:
+		s.Skipped++
+	}
+}
+
+// junitCaseNames counts results of each checker to name test cases.
+type junitCaseNames map[string]int
+
+func (n junitCaseNames) next(checker string) string {
+	n[checker]++
+	return fmt.Sprintf("%s#%d", checker, n[checker])
+}
+
+func newJUnitTestCase(r *base.CheckResult, name, classname string) *junitTestCase {
+	c := &junitTestCase{
+		Name:      name,
+		Classname: classname,
+		Time:      formatJUnitTime(r.Duration),
+		SystemOut: r.Description,
+	}
+	status := r.GetStatus()
+	switch status {
+	case base.StatusFail:
+		c.Failure = &junitMessage{Message: r.Error, Type: string(r.GetSeverity()), Text: formatJUnitDetails(r)}
+	case base.StatusErrored:
+		c.Error = &junitMessage{Message: r.Error, Type: string(status), Text: formatJUnitDetails(r)}
+	case base.StatusSkipped:
+		c.Skipped = &junitMessage{Message: r.Description}
+	case base.StatusWarn:
+		c.SystemOut = "Warning: " + formatJUnitDetails(r)
+	}
+	return c
+}
+
+// formatJUnitDetails formats everything a failure carries for reading in CI.
+func formatJUnitDetails(r *base.CheckResult) string {
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "%s\n", r.Error)
+	fmt.Fprintf(&sb, "Description: %s\n", r.Description)
+	fmt.Fprintf(&sb, "Severity: %s\n", r.GetSeverity())
+	if len(r.Recommendations) > 0 {
+		fmt.Fprintf(&sb, "Recommendations:\n")
+		for i, rec := range r.Recommendations {
+			fmt.Fprintf(&sb, "[%d] %s\n", i+1, rec)
+		}
+	}
+	if len(r.Logs) > 0 {
+		fmt.Fprintf(&sb, "Logs:\n")
+		for _, l := range r.Logs {
+			fmt.Fprintf(&sb, "%s\n", l)
+		}
+	}
+	if len(r.HelpLinks) > 0 {
+		fmt.Fprintf(&sb, "Help links:\n")
+		for i, l := range r.HelpLinks {
+			fmt.Fprintf(&sb, "[%d] %s\n", i+1, l)
+		}
+	}
+	return sb.String()
+}
+
+func writeJUnit(w io.Writer, suites *junitTestSuites) error {
+	for _, s := range suites.Suites {
+		suites.Tests += s.Tests
+		suites.Failures += s.Failures
+		suites.Errors += s.Errors
+		suites.Skipped += s.Skipped
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "    ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatJUnitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func formatJUnitTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05")
+}
@@ -0,0 +1,73 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/batch"
+)
+
+func TestJUnitWriteResults(t *testing.T) {
+	results := []*base.CheckResult{
+		{Checker: "Dns", Description: "Query example.com", Duration: time.Second},
+		{Checker: "Dns", Description: "Query bing.com", Error: "Timeout", Recommendations: []string{"Check network"}, Logs: []string{"dial udp"}},
+		{Checker: "OOM", Status: base.StatusErrored, Error: "Permission denied"},
+		{Checker: "Root", Status: base.StatusSkipped, Description: "Skipped because it requires root"},
+		{Checker: "Disk", Status: base.StatusWarn, Error: "Disk 85% used"},
+	}
+	var out bytes.Buffer
+	if err := (&JUnitFormatter{}).WriteResults(&out, results); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatalf("Expect valid XML but got %s:\n%s", err, out.String())
+	}
+	if len(suites.Suites) != 4 || suites.Tests != 5 || suites.Failures != 1 || suites.Errors != 1 || suites.Skipped != 1 {
+		t.Errorf("Unexpected suites: %+v", suites)
+	}
+	dns := suites.Suites[0]
+	if dns.Name != "Dns" || dns.Time != "1.000" || dns.Cases[1].Failure == nil {
+		t.Errorf("Unexpected suite: %+v", dns)
+	}
+	if dns.Cases[0].Name != "Dns#1" || dns.Cases[1].Name != "Dns#2" || dns.Cases[0].SystemOut != "Query example.com" {
+		t.Errorf("Expect test cases named by checker with descriptions in output but got %+v, %+v", dns.Cases[0], dns.Cases[1])
+	}
+	text := dns.Cases[1].Failure.Text
+	if !strings.Contains(text, "[1] Check network") || !strings.Contains(text, "dial udp") {
+		t.Errorf("Expect recommendations and logs in failure but got:\n%s", text)
+	}
+	if suites.Suites[3].Cases[0].Failure != nil || !strings.Contains(suites.Suites[3].Cases[0].SystemOut, "Disk 85% used") {
+		t.Errorf("Expect warning to pass with output but got %+v", suites.Suites[3].Cases[0])
+	}
+}
+
+func TestJUnitWriteBatchResults(t *testing.T) {
+	run := &batch.BatchRun{
+		Results: []*batch.BatchResult{
+			{Machine: "node-1", CheckResults: []*base.CheckResult{{Checker: "Dns"}}},
+			{Machine: "node-0", Error: errors.New("unreachable")},
+		},
+	}
+	var out bytes.Buffer
+	if err := (&JUnitFormatter{}).WriteBatchResults(&out, run); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(out.Bytes(), &suites); err != nil {
+		t.Fatalf("Expect valid XML but got %s:\n%s", err, out.String())
+	}
+	if len(suites.Suites) != 2 || suites.Suites[0].Name != "node-0" || suites.Suites[0].Errors != 1 {
+		t.Errorf("Expect a suite per machine but got %+v", suites.Suites)
+	}
+	if c := suites.Suites[1].Cases[0]; c.Classname != "node-1.Dns" || c.Name != "Dns#1" {
+		t.Errorf("Unexpected test case: %+v", c)
+	}
+}
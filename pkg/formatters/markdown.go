@@ -0,0 +1,145 @@
+package formatters
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/batch"
+)
+
+var backticksRegex = regexp.MustCompile("`+")
+
+// MarkdownFormatter writes results as Markdown to paste into issues and chat.
+type MarkdownFormatter struct{}
+
+func (f *MarkdownFormatter) WriteResults(w io.Writer, results []*base.CheckResult) error {
+	return f.WriteReport(w, base.NewReport(results))
+}
+
+func (f *MarkdownFormatter) WriteReport(w io.Writer, report *base.Report) error {
+	title := "kdebug results"
+	if report.Hostname != "" {
+		title += " of " + report.Hostname
+	}
+	fmt.Fprintf(w, "## %s\n\n", title)
+	writeMarkdownResults(w, report.Results, "###")
+	return nil
+}
+
+func (f *MarkdownFormatter) WriteBatchResults(w io.Writer, run *batch.BatchRun) error {
+	report := batch.NewReport(run)
+	fmt.Fprintf(w, "## kdebug results of %d machines\n\n", report.Summary.Machines)
+	summary := formatMarkdownSummary(report.Summary.Checks())
+	if report.Summary.MachinesErrored > 0 {
+		summary += fmt.Sprintf(" **%d machines failed to run.**", report.Summary.MachinesErrored)
+	}
+	fmt.Fprintf(w, "%s\n\n", summary)
+
+	fmt.Fprintf(w, "| Machine | Pass | Warn | Fail | Errored | Skipped | Problems |\n")
+	fmt.Fprintf(w, "| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, m := range report.Machines {
+		name := machineName(m)
+		if m.Error != "" {
+			fmt.Fprintf(w, "| %s | | | | | | %s |\n", escapeMarkdownCell(name), escapeMarkdownCell("Error: "+m.Error))
+			continue
+		}
+		s := base.Summarize(m.Results)
+		fmt.Fprintf(w, "| %s | %d | %d | %d | %d | %d | %s |\n", escapeMarkdownCell(name),
+			s.Pass, s.Warn, s.Fail, s.Errored, s.Skipped,
+			escapeMarkdownCell(strings.Join(problemCheckers(m.Results), ", ")))
+	}
+
+	for _, m := range report.Machines {
+		if m.Error == "" && base.Summarize(m.Results).Problems() == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n### %s\n\n", machineName(m))
+		if m.Error != "" {
+			fmt.Fprintf(w, "Error: %s\n", m.Error)
+			continue
+		}
+		writeMarkdownResults(w, m.Results, "####")
+	}
+	return nil
+}
+
+// writeMarkdownResults writes the summary and a section per checker with problems.
+func writeMarkdownResults(w io.Writer, results []*base.CheckResult, heading string) {
+	summary := base.Summarize(results)
+	if summary.Problems() == 0 {
+		fmt.Fprintf(w, "All %d checks passed!%s\n", summary.Pass, formatSkipped(summary))
+		return
+	}
+	fmt.Fprintf(w, "%s\n", formatMarkdownSummary(summary))
+
+	for _, checker := range problemCheckers(results) {
+		fmt.Fprintf(w, "\n%s %s\n", heading, checker)
+		for _, r := range results {
+			if r.Checker != checker || r.Ok() {
+				continue
+			}
+			fmt.Fprintf(w, "\n- **Status:** %s\n", r.GetStatus())
+			fmt.Fprintf(w, "- **Severity:** %s\n", r.GetSeverity())
+			if r.Error != "" {
+				fmt.Fprintf(w, "- **Error:** %s\n", r.Error)
+			}
+			if r.Description != "" {
+				fmt.Fprintf(w, "- **Description:** %s\n", r.Description)
+			}
+			if len(r.Recommendations) > 0 {
+				fmt.Fprintf(w, "\n**Recommendations:**\n\n")
+				for _, rec := range r.Recommendations {
+					fmt.Fprintf(w, "- %s\n", rec)
+				}
+			}
+			if len(r.Logs) > 0 {
+				logs := strings.Join(r.Logs, "\n")
+				fence := markdownFence(logs)
+				fmt.Fprintf(w, "\n**Logs:**\n\n%s\n%s\n%s\n", fence, logs, fence)
+			}
+			if len(r.HelpLinks) > 0 {
+				fmt.Fprintf(w, "\n**Help links:**\n\n")
+				for _, l := range r.HelpLinks {
+					fmt.Fprintf(w, "- %s\n", l)
+				}
+			}
+		}
+	}
+}
+
+// problemCheckers returns names of checkers with problems in the order of results.
+func problemCheckers(results []*base.CheckResult) []string {
+	checkers := []string{}
+	seen := map[string]bool{}
+	for _, r := range results {
+		if !r.Ok() && !seen[r.Checker] {
+			seen[r.Checker] = true
+			checkers = append(checkers, r.Checker)
+		}
+	}
+	return checkers
+}
+
+func formatMarkdownSummary(s base.Summary) string {
+	return fmt.Sprintf("**%d checks passed. %d warnings. %d failed. %d errored.**%s",
+		s.Pass, s.Warn, s.Fail, s.Errored, formatSkipped(s))
+}
+
+// markdownFence returns a code fence longer than any backticks in content.
+func markdownFence(content string) string {
+	n := 3
+	for _, b := range backticksRegex.FindAllString(content, -1) {
+		if len(b) >= n {
+			n = len(b) + 1
+		}
+	}
+	return strings.Repeat("`", n)
+}
+
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,74 @@
+package formatters
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure/kdebug/pkg/base"
+	"github.com/Azure/kdebug/pkg/batch"
+)
+
+func TestMarkdownWriteResults(t *testing.T) {
+	results := []*base.CheckResult{
+		{Checker: "Dns"},
+		{
+			Checker:         "OOM",
+			Error:           "Process app killed",
+			Recommendations: []string{"Increase memory limit"},
+			Logs:            []string{"oom-kill: ```app```"},
+			HelpLinks:       []string{"https://example.com/oom"},
+		},
+	}
+	var out bytes.Buffer
+	if err := (&MarkdownFormatter{}).WriteResults(&out, results); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	md := out.String()
+	for _, s := range []string{
+		"**1 checks passed. 0 warnings. 1 failed. 0 errored.**",
+		"### OOM\n",
+		"- **Error:** Process app killed\n",
+		"**Recommendations:**\n\n- Increase memory limit\n",
+		"````\noom-kill: ```app```\n````\n",
+		"- https://example.com/oom\n",
+	} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Expect %q in markdown:\n%s", s, md)
+		}
+	}
+	if strings.Contains(md, "### Dns") || strings.Contains(md, "\x1b[") {
+		t.Errorf("Expect only problems without colors:\n%s", md)
+	}
+}
+
+func TestMarkdownWriteBatchResults(t *testing.T) {
+	run := &batch.BatchRun{
+		Results: []*batch.BatchResult{
+			{Machine: "node-1", CheckResults: []*base.CheckResult{{Checker: "Dns"}, {Checker: "OOM", Error: "killed"}}},
+			{Machine: "node-0", Error: errors.New("exit | 255")},
+			{Machine: "node-2", CheckResults: []*base.CheckResult{{Checker: "Dns"}}},
+		},
+	}
+	var out bytes.Buffer
+	if err := (&MarkdownFormatter{}).WriteBatchResults(&out, run); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	md := out.String()
+	for _, s := range []string{
+		"**2 checks passed. 0 warnings. 1 failed. 0 errored.** **1 machines failed to run.**",
+		"| node-0 | | | | | | Error: exit \\| 255 |\n",
+		"| node-1 | 1 | 0 | 1 | 0 | 0 | OOM |\n",
+		"| node-2 | 1 | 0 | 0 | 0 | 0 |  |\n",
+		"### node-1\n",
+		"#### OOM\n",
+	} {
+		if !strings.Contains(md, s) {
+			t.Errorf("Expect %q in markdown:\n%s", s, md)
+		}
+	}
+	if strings.Contains(md, "### node-2") {
+		t.Errorf("Expect no section of healthy machines:\n%s", md)
+	}
+}
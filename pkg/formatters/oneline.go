@@ -3,6 +3,7 @@ package formatters
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/Azure/kdebug/pkg/base"
@@ -14,38 +15,37 @@ import (
 type OneLineFormatter struct{}
 
 func (f *OneLineFormatter) WriteResults(w io.Writer, results []*base.CheckResult) error {
-	failedCheckers := make(map[string]struct{})
-	failures := []*base.CheckResult{}
+	problemCheckers := make(map[string]struct{})
 	for _, r := range results {
 		if r.Ok() {
 			if log.IsLevelEnabled(log.DebugLevel) {
-				fmt.Fprintf(w, "[%s] %s\n", r.Checker, r.Description)
+				fmt.Fprintf(w, "[%s] [%s] %s\n", r.Checker, r.GetStatus(), r.Description)
 			}
 		} else {
-			failures = append(failures, r)
-			failedCheckers[r.Checker] = struct{}{}
+			problemCheckers[r.Checker] = struct{}{}
 		}
 	}
 
-	if len(failures) == 0 {
-		fmt.Fprintf(w, "All %v checks passed!\n",
-			color.GreenString("%d", len(results)))
+	summary := base.Summarize(results)
+	if summary.Problems() == 0 {
+		fmt.Fprintf(w, "All %v checks passed!%s\n",
+			color.GreenString("%d", summary.Pass), formatSkipped(summary))
 		return nil
 	}
 
-	failedCheckersList := []string{}
-	for c := range failedCheckers {
-		failedCheckersList = append(failedCheckersList, c)
+	problemCheckersList := []string{}
+	for c := range problemCheckers {
+		problemCheckersList = append(problemCheckersList, c)
 	}
+	sort.Strings(problemCheckersList)
 
-	fmt.Fprintf(w, "%v checks passed, %v failed: %s",
-		color.GreenString("%d", len(results)-len(failures)),
-		color.RedString("%d", len(failures)),
-		strings.Join(failedCheckersList, ", "))
+	fmt.Fprintf(w, "%s Problems: %s",
+		formatSummary(summary),
+		strings.Join(problemCheckersList, ", "))
 
 	return nil
 }
 
-func (f *OneLineFormatter) WriteBatchResults(w io.Writer, results []*batch.BatchResult) error {
+func (f *OneLineFormatter) WriteBatchResults(w io.Writer, run *batch.BatchRun) error {
 	return fmt.Errorf("not implemented: one line formatter for batch results")
 }
@@ -13,34 +13,35 @@ import (
 type TextFormatter struct{}
 
 func (f *TextFormatter) WriteResults(w io.Writer, results []*base.CheckResult) error {
-	failures := []*base.CheckResult{}
+	problems := []*base.CheckResult{}
 	for _, r := range results {
 		if r.Ok() {
 			if log.IsLevelEnabled(log.DebugLevel) {
-				fmt.Fprintf(w, "[%s] %s\n", r.Checker, r.Description)
+				fmt.Fprintf(w, "[%s] [%s] %s\n", r.Checker, r.GetStatus(), r.Description)
 			}
 		} else {
-			failures = append(failures, r)
+			problems = append(problems, r)
 		}
 	}
 
 	fmt.Fprintf(w, "------------------------------\n")
 
-	if len(failures) == 0 {
-		fmt.Fprintf(w, "All %v checks passed!\n",
-			color.GreenString("%d", len(results)))
+	summary := base.Summarize(results)
+	if summary.Problems() == 0 {
+		fmt.Fprintf(w, "All %v checks passed!%s\n",
+			color.GreenString("%d", summary.Pass), formatSkipped(summary))
 		return nil
 	}
 
-	fmt.Fprintf(w, "%v checks passed. %v failed.\n",
-		color.GreenString("%d", len(results)-len(failures)),
-		color.RedString("%d", len(failures)))
+	fmt.Fprintf(w, "%s\n", formatSummary(summary))
 	fmt.Fprintf(w, "------------------------------\n")
 	fmt.Fprintf(w, "kdebug has detected these problems for you:\n")
 
-	for _, r := range failures {
+	for _, r := range problems {
 		fmt.Fprintf(w, "------------------------------\n")
 		fmt.Fprintf(w, color.YellowString("Checker: %s\n", r.Checker))
+		fmt.Fprintf(w, "Status: %s\n", colorStatus(r.GetStatus()))
+		fmt.Fprintf(w, "Severity: %s\n", r.GetSeverity())
 		fmt.Fprintf(w, "Error: %s\n", r.Error)
 		fmt.Fprintf(w, "Description: %s\n", r.Description)
 		if len(r.Recommendations) > 0 {
@@ -67,12 +68,19 @@ func (f *TextFormatter) WriteResults(w io.Writer, results []*base.CheckResult) e
 	return nil
 }
 
-func (f *TextFormatter) WriteBatchResults(w io.Writer, results []*batch.BatchResult) error {
-	for _, result := range results {
-		fmt.Fprintf(w, color.BlueString("=============== Machine: %s ===============\n",
-			result.Machine))
+func (f *TextFormatter) WriteBatchResults(w io.Writer, run *batch.BatchRun) error {
+	for _, result := range run.Results {
+		if result.Cluster != "" {
+			fmt.Fprintf(w, color.BlueString("=============== Cluster: %s ===============\n",
+				result.Cluster))
+		} else {
+			fmt.Fprintf(w, color.BlueString("=============== Machine: %s ===============\n",
+				result.Machine))
+		}
 		if result.Error == nil {
 			f.WriteResults(w, result.CheckResults)
+		} else if result.Cluster != "" {
+			fmt.Fprintf(w, "Cluster error: %s\n", result.Error)
 		} else {
 			fmt.Fprintf(w, "Remote execution error: %s\n", result.Error)
 		}
@@ -0,0 +1,177 @@
+// Package kdebug runs kdebug checkers from Go programs. Custom binaries can
+// bundle their own checkers and tools with checker.Register and tools.Register
+// before creating a Runner.
+package kdebug
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/Azure/kdebug/pkg/base"
+	chks "github.com/Azure/kdebug/pkg/checkers"
+	"github.com/Azure/kdebug/pkg/checkers/kube/poddiagnosis"
+	"github.com/Azure/kdebug/pkg/config"
+	"github.com/Azure/kdebug/pkg/env"
+	"github.com/Azure/kdebug/pkg/formatters"
+	"github.com/Azure/kdebug/pkg/kubecache"
+)
+
+const DefaultParallelism = 4
+
+type Options struct {
+	// Checkers to run, optionally with parameters, e.g. dns:server=10.0.0.10.
+	// All checkers run if neither Checkers nor Groups are given.
+	Checkers []string
+	// Groups are tags of checkers to run, e.g. network.
+	Groups []string
+	// Skip are names of checkers to exclude.
+	Skip []string
+	// Pod is the pod to diagnose. Only checkers tagged pod run if neither Checkers nor Groups are given.
+	Pod base.PodRef
+	// Config overrides settings of checkers.
+	Config *config.Config
+	// Environment is detected when not set.
+	Environment env.Environment
+	// KubeClient is required by Kubernetes checkers. They are skipped when not set.
+	KubeClient    kubernetes.Interface
+	DynamicClient dynamic.Interface
+	// KubeConfig is the REST config the clients are built with.
+	KubeConfig *rest.Config
+	// KubeScope limits namespaces and labels of objects Kubernetes checkers look into.
+	KubeScope kubecache.Scope
+	// Timeout is the default time budget of a single checker. Defaults to checker.DefaultTimeout.
+	Timeout time.Duration
+	// CheckerTimeouts overrides Timeout for checkers by name.
+	CheckerTimeouts map[string]time.Duration
+	// Parallelism is the max number of checkers running at the same time. Defaults to DefaultParallelism.
+	Parallelism int
+	// Formatter writes results. Defaults to the text formatter.
+	Formatter formatters.Formatter
+	// Output is where results are written. Defaults to stdout.
+	Output io.Writer
+}
+
+type Runner struct {
+	opts     Options
+	checkers []string
+	params   map[string]map[string]string
+}
+
+// NewRunner selects checkers and validates the config of them.
+func NewRunner(opts Options) (*Runner, error) {
+	names, params, err := chks.ParseCheckerSpecs(opts.Checkers)
+	if err != nil {
+		return nil, err
+	}
+	groups := opts.Groups
+	if opts.Pod.Name != "" && len(names) == 0 && len(groups) == 0 {
+		groups = []string{poddiagnosis.Tag}
+	}
+	checkers, err := chks.SelectCheckers(names, groups, opts.Skip)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.KubeScope.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Config != nil {
+		if err := chks.ValidateConfig(opts.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Environment == nil {
+		opts.Environment = env.NewEnvironment(env.Options{KubeClient: opts.KubeClient})
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = chks.DefaultTimeout
+	}
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = DefaultParallelism
+	}
+	if opts.Formatter == nil {
+		opts.Formatter = &formatters.TextFormatter{}
+	}
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+
+	return &Runner{
+		opts:     opts,
+		checkers: checkers,
+		params:   params,
+	}, nil
+}
+
+// Checkers returns names of the selected checkers.
+func (r *Runner) Checkers() []string {
+	return r.checkers
+}
+
+// CheckerSpecs returns the selected checkers with their parameters,
+// in the form accepted by Options.Checkers.
+func (r *Runner) CheckerSpecs() []string {
+	specs := make([]string, 0, len(r.checkers))
+	for _, name := range r.checkers {
+		specs = append(specs, chks.FormatCheckerSpec(name, r.params[name]))
+	}
+	return specs
+}
+
+// CheckContext returns the context checkers run with.
+func (r *Runner) CheckContext(ctx context.Context) *base.CheckContext {
+	var cache *kubecache.Cache
+	if r.opts.KubeClient != nil {
+		cache = kubecache.New(r.opts.KubeClient)
+		cache.Scope = r.opts.KubeScope
+	}
+	var configs map[string]*config.Section
+	if r.opts.Config != nil {
+		configs = r.opts.Config.Checkers
+	}
+	return &base.CheckContext{
+		Environment:     r.opts.Environment,
+		KubeClient:      r.opts.KubeClient,
+		DynamicClient:   r.opts.DynamicClient,
+		KubeConfig:      r.opts.KubeConfig,
+		KubeCache:       cache,
+		Output:          r.opts.Output,
+		Context:         ctx,
+		Timeout:         r.opts.Timeout,
+		CheckerTimeouts: r.opts.CheckerTimeouts,
+		Parallelism:     r.opts.Parallelism,
+		CheckerParams:   r.params,
+		CheckerConfigs:  configs,
+		Pod:             r.opts.Pod,
+	}
+}
+
+// Check runs the selected checkers. Cancelling ctx stops in-flight checkers,
+// and results collected so far are still returned.
+func (r *Runner) Check(ctx context.Context) ([]*base.CheckResult, error) {
+	return chks.Check(r.CheckContext(ctx), r.checkers)
+}
+
+// Run runs the selected checkers and writes results with the formatter.
+func (r *Runner) Run(ctx context.Context) ([]*base.CheckResult, error) {
+	start := time.Now()
+	results, err := r.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := r.opts.Formatter.(formatters.ReportFormatter); ok {
+		report := base.NewReport(results)
+		report.StartTime = start
+		report.Environment = r.opts.Environment.GetFacts()
+		err = f.WriteReport(r.opts.Output, report)
+	} else {
+		err = r.opts.Formatter.WriteResults(r.opts.Output, results)
+	}
+	return results, err
+}
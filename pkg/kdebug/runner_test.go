@@ -0,0 +1,231 @@
+package kdebug
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/kdebug/pkg/base"
+	checker "github.com/Azure/kdebug/pkg/checkers"
+	"github.com/Azure/kdebug/pkg/config"
+	"github.com/Azure/kdebug/pkg/env"
+	"github.com/Azure/kdebug/pkg/formatters"
+	"github.com/Azure/kdebug/pkg/kubecache"
+)
+
+type customChecker struct{}
+
+func (c *customChecker) Name() string {
+	return "Custom"
+}
+
+func (c *customChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{
+		Description: "A custom checker.",
+		Tags:        []string{"custom"},
+		Parameters:  map[string]string{"fail": "Fail the check"},
+	}
+}
+
+func (c *customChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	result := &base.CheckResult{
+		Checker:     c.Name(),
+		Description: "Custom check",
+	}
+	if ctx.Params["fail"] == "true" {
+		result.Error = "Custom check failed"
+	}
+	return []*base.CheckResult{result}, nil
+}
+
+type configuredChecker struct{}
+
+type configuredConfig struct {
+	Greeting string `yaml:"greeting"`
+}
+
+func (c *configuredChecker) loadConfig(section *config.Section) (configuredConfig, error) {
+	cfg := configuredConfig{Greeting: "hello"}
+	if section == nil {
+		return cfg, nil
+	}
+	err := section.Decode(&cfg)
+	return cfg, err
+}
+
+func (c *configuredChecker) ValidateConfig(section *config.Section) error {
+	_, err := c.loadConfig(section)
+	return err
+}
+
+func (c *configuredChecker) Name() string {
+	return "Configured"
+}
+
+func (c *configuredChecker) Metadata() base.CheckerMetadata {
+	return base.CheckerMetadata{Description: "A configured checker."}
+}
+
+func (c *configuredChecker) Check(ctx *base.CheckContext) ([]*base.CheckResult, error) {
+	cfg, err := c.loadConfig(ctx.Config)
+	if err != nil {
+		return nil, err
+	}
+	return []*base.CheckResult{{Checker: c.Name(), Description: cfg.Greeting}}, nil
+}
+
+func TestRunner(t *testing.T) {
+	if err := checker.Register("custom", &customChecker{}); err != nil {
+		t.Fatalf("Fail to register checker: %s", err)
+	}
+	if err := checker.Register("custom", &customChecker{}); err == nil {
+		t.Errorf("Expect error registering a checker twice")
+	}
+
+	var out bytes.Buffer
+	runner, err := NewRunner(Options{
+		Checkers:    []string{"dummy", "custom:fail=true"},
+		Environment: &env.StaticEnvironment{},
+		Formatter:   &formatters.OneLineFormatter{},
+		Output:      &out,
+	})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if !reflect.DeepEqual(runner.CheckerSpecs(), []string{"dummy", "custom:fail=true"}) {
+		t.Errorf("Unexpected checker specs: %v", runner.CheckerSpecs())
+	}
+
+	results, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	summary := base.Summarize(results)
+	if summary.Pass != 1 || summary.Fail != 1 {
+		t.Errorf("Expect 1 passed and 1 failed but got %+v", summary)
+	}
+	if !strings.Contains(out.String(), "Custom") {
+		t.Errorf("Expect results written to output but got %q", out.String())
+	}
+
+	runner, err = NewRunner(Options{Groups: []string{"custom"}, Environment: &env.StaticEnvironment{}})
+	if err != nil || !reflect.DeepEqual(runner.Checkers(), []string{"custom"}) {
+		t.Errorf("Expect custom checker selected by group but got %v, %v", runner.Checkers(), err)
+	}
+
+	if _, err := NewRunner(Options{Checkers: []string{"nosuchchecker"}}); err == nil {
+		t.Errorf("Expect error for unknown checker")
+	}
+}
+
+func TestRunnerKubeScope(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	scope := kubecache.Scope{Namespaces: []string{"default"}}
+	runner, err := NewRunner(Options{
+		Checkers:    []string{"kubepod"},
+		Environment: &env.StaticEnvironment{},
+		KubeClient:  client,
+		KubeScope:   scope,
+	})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	ctx := runner.CheckContext(context.Background())
+	if ctx.KubeCache == nil || !reflect.DeepEqual(ctx.KubeCache.Scope, scope) {
+		t.Errorf("Expect kube cache with scope %+v but got %+v", scope, ctx.KubeCache)
+	}
+
+	scope.AllNamespaces = true
+	if _, err := NewRunner(Options{KubeScope: scope}); err == nil {
+		t.Errorf("Expect error of conflicting namespaces")
+	}
+}
+
+func TestRunnerPod(t *testing.T) {
+	pod := base.PodRef{Namespace: "default", Name: "web-0"}
+	runner, err := NewRunner(Options{Pod: pod, Environment: &env.StaticEnvironment{}})
+	if err != nil || !reflect.DeepEqual(runner.Checkers(), []string{"poddiagnosis"}) {
+		t.Fatalf("Expect only pod checkers selected but got %v, %v", runner.Checkers(), err)
+	}
+	if ctx := runner.CheckContext(context.Background()); ctx.Pod != pod {
+		t.Errorf("Expect pod %s in check context but got %s", pod, ctx.Pod)
+	}
+
+	runner, err = NewRunner(Options{Pod: pod, Checkers: []string{"dummy"}, Environment: &env.StaticEnvironment{}})
+	if err != nil || !reflect.DeepEqual(runner.Checkers(), []string{"dummy"}) {
+		t.Errorf("Expect given checkers selected but got %v, %v", runner.Checkers(), err)
+	}
+}
+
+func TestRunnerReport(t *testing.T) {
+	var out bytes.Buffer
+	runner, err := NewRunner(Options{
+		Checkers:    []string{"dummy"},
+		Environment: &env.StaticEnvironment{Facts: env.Facts{OS: "linux"}},
+		Formatter:   &formatters.JsonFormatter{},
+		Output:      &out,
+	})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if _, err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+
+	report, err := base.DecodeReport(out.Bytes())
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	if report.SchemaVersion != base.ReportSchemaVersion || report.Environment == nil || report.Environment.OS != "linux" {
+		t.Errorf("Expect report with environment but got %+v", report)
+	}
+	if len(report.Results) != 1 || report.EndTime.Before(report.StartTime) {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+func TestRunnerConfig(t *testing.T) {
+	if err := checker.Register("configured", &configuredChecker{}); err != nil {
+		t.Fatalf("Fail to register checker: %s", err)
+	}
+	cfg, err := config.Parse([]byte("checkers:\n  configured:\n    greeting: hi\n"))
+	if err != nil {
+		t.Fatalf("Fail to parse config: %s", err)
+	}
+
+	configured, err := NewRunner(Options{
+		Checkers:    []string{"configured"},
+		Config:      cfg,
+		Environment: &env.StaticEnvironment{},
+	})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+	// A runner created later without config must not see the config of the first one
+	unconfigured, err := NewRunner(Options{
+		Checkers:    []string{"configured"},
+		Environment: &env.StaticEnvironment{},
+	})
+	if err != nil {
+		t.Fatalf("Expect no error but got %s", err)
+	}
+
+	for runner, expected := range map[*Runner]string{configured: "hi", unconfigured: "hello"} {
+		results, err := runner.Check(context.Background())
+		if err != nil {
+			t.Fatalf("Expect no error but got %s", err)
+		}
+		if len(results) != 1 || results[0].Description != expected {
+			t.Errorf("Expect description %q but got %+v", expected, results)
+		}
+	}
+
+	cfg, _ = config.Parse([]byte("checkers:\n  configured:\n    nosuchkey: 1\n"))
+	if _, err := NewRunner(Options{Checkers: []string{"configured"}, Config: cfg}); err == nil {
+		t.Errorf("Expect error for invalid config")
+	}
+}
@@ -0,0 +1,320 @@
+// Package kubecache lists Kubernetes objects once per run and shares them
+// among checkers.
+package kubecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+const DefaultPageSize = 500
+
+// Query scopes a list. An empty namespace means namespaces of the cache scope.
+type Query struct {
+	Namespace     string
+	LabelSelector string
+	FieldSelector string
+	// IgnoreScopeLabels skips the label selector of the scope, e.g. to find
+	// services selecting a pod whatever labels the services have.
+	IgnoreScopeLabels bool
+	// IgnoreScope skips the whole scope: its namespaces, excluded namespaces,
+	// label selector and fallback namespace, e.g. to count objects of the cluster.
+	IgnoreScope bool
+}
+
+// Scope limits objects returned by the cache for all checkers.
+type Scope struct {
+	// Namespaces to list objects in. All namespaces if empty.
+	Namespaces []string
+	// AllNamespaces disables falling back to FallbackNamespace.
+	AllNamespaces bool
+	// ExcludeNamespaces are dropped from results.
+	ExcludeNamespaces []string
+	// LabelSelector filters pods, config maps, secrets and services.
+	LabelSelector string
+	// FallbackNamespace is listed instead when listing in all namespaces is forbidden,
+	// usually the namespace of the current kubeconfig context.
+	FallbackNamespace string
+}
+
+// Validate checks that options of the scope don't conflict.
+func (s *Scope) Validate() error {
+	if s.AllNamespaces && len(s.Namespaces) > 0 {
+		return fmt.Errorf("Namespaces cannot be specified with all namespaces")
+	}
+	for _, ns := range s.Namespaces {
+		if s.excluded(ns) {
+			return fmt.Errorf("Namespace %s is both included and excluded", ns)
+		}
+	}
+	return nil
+}
+
+func (s *Scope) excluded(namespace string) bool {
+	for _, ns := range s.ExcludeNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+type key struct {
+	resource string
+	query    Query
+}
+
+type entry struct {
+	mu      sync.Mutex
+	done    bool
+	objects []runtime.Object
+	err     error
+}
+
+type resource struct {
+	name string
+	// namespaced resources are listed in namespaces of the scope.
+	namespaced bool
+	// labelled resources are filtered by the label selector of the scope.
+	labelled bool
+	list     func(ctx context.Context, namespace string, opts metav1.ListOptions) (runtime.Object, error)
+}
+
+// Cache lazily lists objects page by page on first access, and returns the
+// same objects to later callers with the same query. It's safe for concurrent use.
+type Cache struct {
+	client   kubernetes.Interface
+	Scope    Scope
+	PageSize int64
+
+	mu      sync.Mutex
+	entries map[key]*entry
+}
+
+func New(client kubernetes.Interface) *Cache {
+	return &Cache{
+		client:   client,
+		PageSize: DefaultPageSize,
+		entries:  map[key]*entry{},
+	}
+}
+
+// get returns the cached objects of the query, or lists them. Failures caused
+// by cancellation of ctx are not cached so that other checkers can retry.
+func (c *Cache) get(ctx context.Context, r resource, q Query) ([]runtime.Object, error) {
+	c.mu.Lock()
+	e, ok := c.entries[key{r.name, q}]
+	if !ok {
+		e = &entry{}
+		c.entries[key{r.name, q}] = e
+	}
+	c.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.done {
+		objects, err := c.fetch(ctx, r, q)
+		if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			return nil, err
+		}
+		e.objects, e.err, e.done = objects, err, true
+	}
+	return e.objects, e.err
+}
+
+func (c *Cache) fetch(ctx context.Context, r resource, q Query) ([]runtime.Object, error) {
+	opts := metav1.ListOptions{
+		LabelSelector: q.LabelSelector,
+		FieldSelector: q.FieldSelector,
+		Limit:         c.PageSize,
+	}
+	if r.labelled && !q.IgnoreScopeLabels && !q.IgnoreScope && c.Scope.LabelSelector != "" {
+		opts.LabelSelector = strings.Trim(opts.LabelSelector+","+c.Scope.LabelSelector, ",")
+	}
+	namespaces := []string{q.Namespace}
+	if r.namespaced && !q.IgnoreScope && q.Namespace == "" && len(c.Scope.Namespaces) > 0 {
+		namespaces = c.Scope.Namespaces
+	}
+
+	objects := []runtime.Object{}
+	for _, ns := range namespaces {
+		nsObjects, err := c.listAll(ctx, r, ns, opts)
+		if apierrors.IsForbidden(err) && r.namespaced && ns == "" && !q.IgnoreScope && !c.Scope.AllNamespaces && c.Scope.FallbackNamespace != "" {
+			log.Warnf("Forbidden to list %s in all namespaces. Fall back to namespace %s. Use --namespace to check other namespaces.",
+				r.name, c.Scope.FallbackNamespace)
+			ns = c.Scope.FallbackNamespace
+			nsObjects, err = c.listAll(ctx, r, ns, opts)
+		}
+		if apierrors.IsForbidden(err) {
+			where := "in all namespaces"
+			if ns != "" {
+				where = "in namespace " + ns
+			} else if !r.namespaced {
+				where = "of the cluster"
+			}
+			if q.IgnoreScope {
+				return nil, fmt.Errorf("Forbidden to list %s %s: %s", r.name, where, err)
+			}
+			return nil, fmt.Errorf("Forbidden to list %s %s. Use --namespace to check namespaces you have access to: %s", r.name, where, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range nsObjects {
+			if accessor, err := meta.Accessor(obj); err == nil && !q.IgnoreScope && c.Scope.excluded(accessor.GetNamespace()) {
+				continue
+			}
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// listAll lists objects in namespace page by page.
+func (c *Cache) listAll(ctx context.Context, r resource, namespace string, opts metav1.ListOptions) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+	for {
+		list, err := r.list(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, items...)
+		listMeta, err := meta.ListAccessor(list)
+		if err != nil {
+			return nil, err
+		}
+		if listMeta.GetContinue() == "" {
+			return objects, nil
+		}
+		opts.Continue = listMeta.GetContinue()
+	}
+}
+
+func (c *Cache) Pods(ctx context.Context, q Query) ([]corev1.Pod, error) {
+	objects, err := c.get(ctx, resource{
+		name:       "pods",
+		namespaced: true,
+		labelled:   true,
+		list: func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.client.CoreV1().Pods(ns).List(ctx, opts)
+		},
+	}, q)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Pod, 0, len(objects))
+	for _, obj := range objects {
+		items = append(items, *obj.(*corev1.Pod))
+	}
+	return items, nil
+}
+
+func (c *Cache) Secrets(ctx context.Context, q Query) ([]corev1.Secret, error) {
+	objects, err := c.get(ctx, resource{
+		name:       "secrets",
+		namespaced: true,
+		labelled:   true,
+		list: func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.client.CoreV1().Secrets(ns).List(ctx, opts)
+		},
+	}, q)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Secret, 0, len(objects))
+	for _, obj := range objects {
+		items = append(items, *obj.(*corev1.Secret))
+	}
+	return items, nil
+}
+
+func (c *Cache) ConfigMaps(ctx context.Context, q Query) ([]corev1.ConfigMap, error) {
+	objects, err := c.get(ctx, resource{
+		name:       "configmaps",
+		namespaced: true,
+		labelled:   true,
+		list: func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.client.CoreV1().ConfigMaps(ns).List(ctx, opts)
+		},
+	}, q)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.ConfigMap, 0, len(objects))
+	for _, obj := range objects {
+		items = append(items, *obj.(*corev1.ConfigMap))
+	}
+	return items, nil
+}
+
+// Events are not filtered by the label selector of the scope since events are rarely labelled.
+func (c *Cache) Events(ctx context.Context, q Query) ([]corev1.Event, error) {
+	objects, err := c.get(ctx, resource{
+		name:       "events",
+		namespaced: true,
+		list: func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.client.CoreV1().Events(ns).List(ctx, opts)
+		},
+	}, q)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Event, 0, len(objects))
+	for _, obj := range objects {
+		items = append(items, *obj.(*corev1.Event))
+	}
+	return items, nil
+}
+
+func (c *Cache) Services(ctx context.Context, q Query) ([]corev1.Service, error) {
+	objects, err := c.get(ctx, resource{
+		name:       "services",
+		namespaced: true,
+		labelled:   true,
+		list: func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.client.CoreV1().Services(ns).List(ctx, opts)
+		},
+	}, q)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Service, 0, len(objects))
+	for _, obj := range objects {
+		items = append(items, *obj.(*corev1.Service))
+	}
+	return items, nil
+}
+
+// Nodes ignores the namespace of q and the scope since nodes are cluster scoped.
+func (c *Cache) Nodes(ctx context.Context, q Query) ([]corev1.Node, error) {
+	q.Namespace = ""
+	objects, err := c.get(ctx, resource{
+		name: "nodes",
+		list: func(ctx context.Context, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.client.CoreV1().Nodes().List(ctx, opts)
+		},
+	}, q)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Node, 0, len(objects))
+	for _, obj := range objects {
+		items = append(items, *obj.(*corev1.Node))
+	}
+	return items, nil
+}
@@ -0,0 +1,173 @@
+package kubecache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPodsPaginatedAndCached(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	calls := 0
+	// Serve 5 pods in pages of 2
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		calls++
+		restrictions := action.(k8stesting.ListAction).GetListRestrictions()
+		if restrictions.Labels.String() != "app=web" {
+			t.Errorf("Expect label selector app=web but got %s", restrictions.Labels)
+		}
+		list := &corev1.PodList{}
+		start := (calls - 1) * 2
+		for i := start; i < start+2 && i < 5; i++ {
+			list.Items = append(list.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("pod-%d", i),
+				Labels: map[string]string{"app": "web"},
+			}})
+		}
+		if start+2 < 5 {
+			list.Continue = fmt.Sprintf("page-%d", calls)
+		}
+		return true, list, nil
+	})
+
+	cache := New(client)
+	cache.PageSize = 2
+	q := Query{Namespace: "default", LabelSelector: "app=web"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pods, err := cache.Pods(context.Background(), q)
+			if err != nil || len(pods) != 5 {
+				t.Errorf("Expect 5 pods but got %d, %v", len(pods), err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 3 {
+		t.Errorf("Expect 3 list calls but got %d", calls)
+	}
+}
+
+func TestCancelledListNotCached(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}})
+	cancelled := true
+	client.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if cancelled {
+			return true, nil, context.Canceled
+		}
+		return false, nil, nil
+	})
+
+	cache := New(client)
+	if _, err := cache.Secrets(context.Background(), Query{}); err == nil {
+		t.Errorf("Expect error of cancelled list")
+	}
+	cancelled = false
+	secrets, err := cache.Secrets(context.Background(), Query{})
+	if err != nil || len(secrets) != 1 {
+		t.Errorf("Expect 1 secret after retry but got %d, %v", len(secrets), err)
+	}
+}
+
+func newPod(ns, name string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels}}
+}
+
+func TestScope(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newPod("default", "web", map[string]string{"app": "web"}),
+		newPod("default", "db", map[string]string{"app": "db"}),
+		newPod("team-a", "web", map[string]string{"app": "web"}),
+		newPod("kube-system", "coredns", map[string]string{"app": "web"}),
+	)
+	tests := []struct {
+		scope    Scope
+		expected int
+	}{
+		{Scope{}, 4},
+		{Scope{Namespaces: []string{"default", "team-a"}}, 3},
+		{Scope{ExcludeNamespaces: []string{"kube-system"}}, 3},
+		{Scope{LabelSelector: "app=web", ExcludeNamespaces: []string{"kube-system"}}, 2},
+		{Scope{Namespaces: []string{"default"}, LabelSelector: "app=web"}, 1},
+	}
+	for _, test := range tests {
+		cache := New(client)
+		cache.Scope = test.scope
+		pods, err := cache.Pods(context.Background(), Query{})
+		if err != nil || len(pods) != test.expected {
+			t.Errorf("Expect %d pods in scope %+v but got %d, %v", test.expected, test.scope, len(pods), err)
+		}
+	}
+
+	cache := New(client)
+	cache.Scope = Scope{LabelSelector: "app=db"}
+	pods, err := cache.Pods(context.Background(), Query{IgnoreScopeLabels: true})
+	if err != nil || len(pods) != 4 {
+		t.Errorf("Expect 4 pods ignoring labels of the scope but got %d, %v", len(pods), err)
+	}
+
+	cache = New(client)
+	cache.Scope = Scope{Namespaces: []string{"default"}, ExcludeNamespaces: []string{"kube-system"}, LabelSelector: "app=web"}
+	pods, err = cache.Pods(context.Background(), Query{IgnoreScope: true})
+	if err != nil || len(pods) != 4 {
+		t.Errorf("Expect 4 pods ignoring the scope but got %d, %v", len(pods), err)
+	}
+}
+
+func TestScopeValidate(t *testing.T) {
+	if err := (&Scope{AllNamespaces: true, Namespaces: []string{"default"}}).Validate(); err == nil {
+		t.Errorf("Expect error of namespaces with all namespaces")
+	}
+	if err := (&Scope{Namespaces: []string{"default"}, ExcludeNamespaces: []string{"default"}}).Validate(); err == nil {
+		t.Errorf("Expect error of namespace both included and excluded")
+	}
+	if err := (&Scope{Namespaces: []string{"default"}, ExcludeNamespaces: []string{"kube-system"}}).Validate(); err != nil {
+		t.Errorf("Expect no error but got %s", err)
+	}
+}
+
+func TestForbiddenFallback(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		newPod("default", "web", nil),
+		newPod("team-a", "web", nil),
+	)
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "" {
+			return true, nil, apierrors.NewForbidden(corev1.Resource("pods"), "", fmt.Errorf("cluster scope"))
+		}
+		return false, nil, nil
+	})
+
+	cache := New(client)
+	cache.Scope.FallbackNamespace = "team-a"
+	pods, err := cache.Pods(context.Background(), Query{})
+	if err != nil || len(pods) != 1 || pods[0].Namespace != "team-a" {
+		t.Errorf("Expect pods of fallback namespace but got %+v, %v", pods, err)
+	}
+
+	cache = New(client)
+	cache.Scope = Scope{AllNamespaces: true, FallbackNamespace: "team-a"}
+	_, err = cache.Pods(context.Background(), Query{})
+	if err == nil || !strings.Contains(err.Error(), "--namespace") {
+		t.Errorf("Expect forbidden error suggesting --namespace but got %v", err)
+	}
+
+	cache = New(client)
+	cache.Scope.FallbackNamespace = "team-a"
+	_, err = cache.Pods(context.Background(), Query{IgnoreScope: true})
+	if err == nil {
+		t.Errorf("Expect forbidden error without fallback when ignoring the scope")
+	}
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/Azure/kdebug/pkg/tools/aadssh"
@@ -18,6 +19,19 @@ var allTools = map[string]Tool{
 	"netexec":         netexec.New(),
 }
 
+// Register adds a tool to the registry, e.g. from a custom binary that
+// bundles its own tools. It must be called before running tools.
+func Register(name string, tool Tool) error {
+	if name == "" {
+		return fmt.Errorf("Invalid tool name: %q", name)
+	}
+	if _, ok := allTools[name]; ok {
+		return fmt.Errorf("Tool %s already exists", name)
+	}
+	allTools[name] = tool
+	return nil
+}
+
 func ListAllToolNames() []string {
 	names := make([]string, 0, len(allTools))
 	for n := range allTools {
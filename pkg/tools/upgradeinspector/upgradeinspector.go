@@ -3,6 +3,8 @@ package upgradeinspector
 import (
 	"fmt"
 	"os/exec"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +18,17 @@ const logPath = "/var/log/dpkg.log"
 
 const suggestion = "You can check '/var/log/dpkg.log' and '/var/log/apt/history.log' for further detail."
 
+const rpmSuggestion = "You can check 'rpm -qa --last' and '/var/log/dnf.rpm.log' or '/var/log/tdnf.log' for further detail."
+
+// rpmQueryFormat lists install time, name and version of packages. Older
+// versions of upgraded packages are unknown.
+const rpmQueryFormat = `%{INSTALLTIME} %{NAME} %{VERSION}-%{RELEASE}\n`
+
+var (
+	dpkgDistros = []string{"ubuntu", "debian"}
+	rpmDistros  = []string{"azurelinux", "rhel", "centos", "fedora"}
+)
+
 var columns = []string{
 	"Timestamp",
 	"Package",
@@ -54,11 +67,18 @@ func (t *UpgradeInspectTool) ParseArgs(ctx *base.ToolContext, args []string) err
 
 func (t *UpgradeInspectTool) Run(ctx *base.ToolContext) error {
 	t.parseArgument(ctx)
-	if !envCheck(ctx.Environment) {
-		fmt.Println(color.YellowString("Skip upgrade inspect in non ubuntu/debian os"))
+	switch {
+	case hasAnyFlag(ctx.Environment, dpkgDistros):
+		return t.exec()
+	case hasAnyFlag(ctx.Environment, rpmDistros):
+		return t.execRpm()
+	case ctx.Environment.HasFlag("flatcar"):
+		fmt.Println(color.YellowString("Skip upgrade inspect in flatcar since it updates the OS image as a whole. You can check 'journalctl -u update-engine' for further detail."))
+		return nil
+	default:
+		fmt.Println(color.YellowString("Skip upgrade inspect in os other than %s", strings.Join(append(dpkgDistros, rpmDistros...), "/")))
 		return nil
 	}
-	return t.exec()
 }
 
 func (t *UpgradeInspectTool) parseArgument(ctx *base.ToolContext) {
@@ -78,6 +98,47 @@ func (t *UpgradeInspectTool) exec() error {
 	return nil
 }
 
+func (t *UpgradeInspectTool) execRpm() error {
+	cmd := exec.Command("rpm", "-qa", "--queryformat", rpmQueryFormat)
+	stdout, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	fmt.Println(t.parseResult(rpmToLogs(string(stdout))))
+	fmt.Println(color.YellowString("\n%v\n", rpmSuggestion))
+	return nil
+}
+
+// rpmToLogs converts packages listed by rpm to upgrade logs of dpkg in the order of install time.
+func rpmToLogs(result string) string {
+	type record struct {
+		time    time.Time
+		name    string
+		version string
+	}
+	records := []record{}
+	for _, line := range strings.Split(result, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		sec, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		records = append(records, record{time.Unix(sec, 0), fields[1], fields[2]})
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].time.Before(records[j].time)
+	})
+
+	sb := strings.Builder{}
+	for _, r := range records {
+		sb.WriteString(fmt.Sprintf("%s upgrade %s - %s\n", r.time.Format("2006-01-02 15:04:05"), r.name, r.version))
+	}
+	return sb.String()
+}
+
 func (t *UpgradeInspectTool) parseResult(result string) string {
 	sb := strings.Builder{}
 	logs := t.filterResult(result)
@@ -114,6 +175,11 @@ func (t *UpgradeInspectTool) filterResult(result string) []string {
 	return filtered
 }
 
-func envCheck(environment env.Environment) bool {
-	return environment.HasFlag("ubuntu") || environment.HasFlag("debian")
+func hasAnyFlag(environment env.Environment, flags []string) bool {
+	for _, flag := range flags {
+		if environment.HasFlag(flag) {
+			return true
+		}
+	}
+	return false
 }
@@ -33,3 +33,15 @@ func TestUpgradeParser_Success(t *testing.T) {
 		t.Errorf("UpgradeInspectTool parser output is expected to be\n%s\n, but got\n%s\n", expected, output)
 	}
 }
+
+func TestRpmToLogs(t *testing.T) {
+	newer := time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local)
+	older := newer.Add(-time.Hour)
+	result := fmt.Sprintf("%d openssl 3.0.8-1.azl3\n%d kernel 6.6.14.1-1.azl3\n(none)\n", newer.Unix(), older.Unix())
+
+	expected := "2023-01-02 09:00:00 upgrade kernel - 6.6.14.1-1.azl3\n" +
+		"2023-01-02 10:00:00 upgrade openssl - 3.0.8-1.azl3\n"
+	if logs := rpmToLogs(result); logs != expected {
+		t.Errorf("rpmToLogs output is expected to be\n%s\n, but got\n%s\n", expected, logs)
+	}
+}